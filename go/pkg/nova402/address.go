@@ -0,0 +1,81 @@
+package nova402
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// IsValidEVMAddress reports whether s is a 0x-prefixed 20-byte hex address.
+// All-lowercase and all-uppercase addresses are accepted as is; mixed-case
+// addresses must carry a correct EIP-55 checksum, which catches most typos.
+func IsValidEVMAddress(s string) bool {
+	if !strings.HasPrefix(s, "0x") || !common.IsHexAddress(s) {
+		return false
+	}
+	digits := s[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return true
+	}
+	return s == common.HexToAddress(s).Hex()
+}
+
+// ToChecksumAddress returns the EIP-55 checksummed form of an EVM address. It
+// fails for addresses IsValidEVMAddress rejects, including mixed-case
+// addresses whose checksum is wrong.
+func ToChecksumAddress(s string) (string, error) {
+	if !IsValidEVMAddress(s) {
+		return "", fmt.Errorf("invalid EVM address %q", s)
+	}
+	return common.HexToAddress(s).Hex(), nil
+}
+
+// IsValidSolanaAddress reports whether s is a base58 encoded 32-byte public key
+func IsValidSolanaAddress(s string) bool {
+	_, err := decodeSolanaPublicKey(s)
+	return err == nil
+}
+
+// normalizeAddress returns the form addresses are compared in: lowercase hex
+// for EVM addresses, and s unchanged otherwise since base58 is case-sensitive
+func normalizeAddress(s string) string {
+	if common.IsHexAddress(s) {
+		return strings.ToLower(common.HexToAddress(s).Hex())
+	}
+	return s
+}
+
+// containsAddress reports whether address is in addresses
+func containsAddress(addresses []string, address string) bool {
+	address = normalizeAddress(address)
+	for _, a := range addresses {
+		if normalizeAddress(a) == address {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPayTo fails with ErrPayToNotAllowed when payTo is on PayToDenylist or
+// missing from a non-empty PayToAllowlist
+func (c *Client) checkPayTo(payTo string) error {
+	if containsAddress(c.PayToDenylist, payTo) {
+		return fmt.Errorf("%w: %s is on the denylist", ErrPayToNotAllowed, payTo)
+	}
+	if len(c.PayToAllowlist) > 0 && !containsAddress(c.PayToAllowlist, payTo) {
+		return fmt.Errorf("%w: %s is not on the allowlist", ErrPayToNotAllowed, payTo)
+	}
+	return nil
+}
+
+// isValidAddress checks address against the format of the given network type
+func isValidAddress(address string, networkType NetworkType) bool {
+	switch networkType {
+	case NetworkTypeEVM:
+		return IsValidEVMAddress(address)
+	case NetworkTypeSolana:
+		return IsValidSolanaAddress(address)
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package nova402
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIsValidEVMAddress(t *testing.T) {
+	for network, address := range USDCAddresses {
+		if IsEVMNetwork(network) && !IsValidEVMAddress(address) {
+			t.Fatalf("%s USDC address %s rejected", network, address)
+		}
+	}
+	for address, want := range map[string]bool{
+		"0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913": true,
+		"0x833589fcd6edb6e08f4c7c32d4f71b54bda02913": true,
+		"0x833589FCD6EDB6E08F4C7C32D4F71B54BDA02913": true,
+		"0x833589fcD6eDb6E08f4c7C32D4f71b54bdA02913": false, // bad checksum
+		"0x833589fCD6eDb6E08f4c7C32D4f71b54bdA029":   false,
+		"833589fCD6eDb6E08f4c7C32D4f71b54bdA02913":   false,
+	} {
+		if got := IsValidEVMAddress(address); got != want {
+			t.Fatalf("IsValidEVMAddress(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestToChecksumAddress(t *testing.T) {
+	got, err := ToChecksumAddress("0x833589fcd6edb6e08f4c7c32d4f71b54bda02913")
+	if err != nil || got != "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913" {
+		t.Fatalf("ToChecksumAddress = %s, %v", got, err)
+	}
+	if _, err := ToChecksumAddress("0x1234"); err == nil {
+		t.Fatal("short address accepted")
+	}
+}
+
+func TestIsValidSolanaAddress(t *testing.T) {
+	if !IsValidSolanaAddress("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v") {
+		t.Fatal("USDC mint rejected")
+	}
+	for _, address := range []string{"0x00", "", "0OIl0OIl0OIl0OIl0OIl0OIl0OIl0OIl"} {
+		if IsValidSolanaAddress(address) {
+			t.Fatalf("IsValidSolanaAddress(%q) = true", address)
+		}
+	}
+}
+
+func TestPayToChecksum(t *testing.T) {
+	requirements := testRequirements()
+	requirements.PayTo = "0x209693bc6afc0c5328ba36faf03c514ef312287c"
+	if err := requirements.Validate(); err != nil {
+		t.Fatalf("lower-case payee rejected: %v", err)
+	}
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	auth, err := c.buildAuthorization(context.Background(), requirements, 0, time.Now().Unix()+60)
+	if err != nil {
+		t.Fatalf("buildAuthorization: %v", err)
+	}
+	if auth.To != "0x209693Bc6afc0C5328bA36FaF03C514EF312287C" {
+		t.Fatalf("authorization to %s, want the checksummed payee", auth.To)
+	}
+
+	requirements.PayTo = "0x209693bC6afc0C5328bA36FaF03C514EF312287C"
+	if err := requirements.Validate(); err == nil {
+		t.Fatal("payee with a mistyped checksum accepted")
+	}
+}
@@ -0,0 +1,96 @@
+package nova402
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ParseAmount converts a human readable amount such as "1.50" into base units
+// for a token with the given decimals ("1500000" for 6 decimals)
+func ParseAmount(human string, decimals int) (string, error) {
+	if decimals < 0 {
+		return "", fmt.Errorf("invalid decimals: %d", decimals)
+	}
+
+	human = strings.TrimSpace(human)
+	whole, frac, hasPoint := strings.Cut(human, ".")
+	if whole == "" && frac == "" {
+		return "", fmt.Errorf("invalid amount %q", human)
+	}
+	if !isDigits(whole) || !isDigits(frac) || (hasPoint && frac == "") {
+		return "", fmt.Errorf("invalid amount %q: must be a non-negative decimal number", human)
+	}
+
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > decimals {
+		return "", fmt.Errorf("invalid amount %q: more than %d decimal places", human, decimals)
+	}
+
+	digits := whole + frac + strings.Repeat("0", decimals-len(frac))
+	base, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid amount %q", human)
+	}
+	return base.String(), nil
+}
+
+// FormatAmount converts a base unit amount into a human readable decimal string.
+// It returns an empty string when base is not a non-negative integer.
+func FormatAmount(base string, decimals int) string {
+	value, ok := new(big.Int).SetString(base, 10)
+	if !ok || value.Sign() < 0 || decimals < 0 {
+		return ""
+	}
+
+	digits := value.String()
+	if decimals == 0 {
+		return digits
+	}
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	whole := digits[:len(digits)-decimals]
+	frac := strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
+
+// USDCAmount converts a human readable USDC amount into base units for a network
+func USDCAmount(network, human string) (string, error) {
+	decimals, err := GetUSDCDecimals(network)
+	if err != nil {
+		return "", err
+	}
+	return ParseAmount(human, decimals)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type amountContextKey struct{}
+
+// WithAmount returns a context that makes a paid request commit base units
+// instead of the maximum, overriding PayAmount for that call only. It applies
+// to "upto" requirements and must not exceed MaxAmountRequired; for any other
+// scheme the payment fails with ErrInvalidAmount unless base equals
+// MaxAmountRequired.
+func WithAmount(ctx context.Context, base string) context.Context {
+	return context.WithValue(ctx, amountContextKey{}, base)
+}
+
+// amountFromContext returns the amount set by WithAmount, if any
+func amountFromContext(ctx context.Context) (string, bool) {
+	base, ok := ctx.Value(amountContextKey{}).(string)
+	return base, ok
+}
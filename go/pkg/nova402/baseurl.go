@@ -0,0 +1,42 @@
+package nova402
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// resolveURL resolves a request URL against BaseURL. Absolute URLs are
+// returned unchanged. A relative path is appended to BaseURL's path, so
+// "/resource" and "resource" both resolve under a BaseURL of
+// "https://api.example.com/v1" or "https://api.example.com/v1/", and its query
+// and fragment are kept.
+func (c *Client) resolveURL(raw string) (string, error) {
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid request URL %q: %w", raw, err)
+	}
+	if ref.IsAbs() {
+		return raw, nil
+	}
+	if c.BaseURL == "" {
+		return "", fmt.Errorf("%w: cannot resolve relative URL %q", ErrNoBaseURL, raw)
+	}
+
+	base, err := url.Parse(c.BaseURL)
+	if err != nil || !base.IsAbs() || base.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q", c.BaseURL)
+	}
+	if ref.Host != "" {
+		// Scheme-relative reference such as "//cdn.example.com/x"
+		return base.ResolveReference(ref).String(), nil
+	}
+
+	resolved := base
+	if ref.Path != "" {
+		resolved = base.JoinPath(ref.Path)
+	}
+	resolved.RawQuery = ref.RawQuery
+	resolved.Fragment = ref.Fragment
+	resolved.RawFragment = ref.RawFragment
+	return resolved.String(), nil
+}
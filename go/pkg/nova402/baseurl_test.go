@@ -0,0 +1,51 @@
+package nova402
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveURL(t *testing.T) {
+	for _, tc := range []struct{ base, ref, want string }{
+		{"https://a.com/v1", "/res", "https://a.com/v1/res"},
+		{"https://a.com/v1/", "res", "https://a.com/v1/res"},
+		{"https://a.com/v1/", "/res?x=1#f", "https://a.com/v1/res?x=1#f"},
+		{"https://a.com", "res/", "https://a.com/res/"},
+		{"https://a.com/v1", "https://b.com/x", "https://b.com/x"},
+		{"https://a.com/v1", "//c.com/y", "https://c.com/y"},
+		{"https://a.com/v1?k=1", "?q=2", "https://a.com/v1?q=2"},
+	} {
+		c := &Client{BaseURL: tc.base}
+		if got, err := c.resolveURL(tc.ref); err != nil || got != tc.want {
+			t.Errorf("resolveURL(%q) against %q = %q, %v; want %q", tc.ref, tc.base, got, err, tc.want)
+		}
+	}
+	if _, err := (&Client{}).resolveURL("/x"); !errors.Is(err, ErrNoBaseURL) {
+		t.Fatalf("relative URL without a base: err = %v, want ErrNoBaseURL", err)
+	}
+}
+
+func TestWithBaseURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithBaseURL(srv.URL+"/api/"))
+	resp, err := c.Get("/resource", nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "/api/resource" {
+		t.Fatalf("server saw path %q, want /api/resource", body)
+	}
+
+	if _, err := NewClientWithOptionsE(WithNetwork("base-sepolia"), WithBaseURL("/rel")); err == nil {
+		t.Fatal("relative base URL accepted")
+	}
+}
@@ -0,0 +1,63 @@
+package nova402
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// DefaultBatchConcurrency is how many batch requests run at once when
+// BatchConcurrency is unset
+const DefaultBatchConcurrency = 4
+
+// BatchRequest is a single GET issued by GetBatch
+type BatchRequest struct {
+	URL     string
+	Headers map[string]string
+}
+
+// BatchResult is the outcome of one BatchRequest
+type BatchResult struct {
+	Response *http.Response
+	Err      error
+}
+
+// GetBatch issues the requests concurrently, each handling its own 402, with
+// at most BatchConcurrency in flight. Results are in the same order as
+// requests; callers must close every non-nil Response body.
+func (c *Client) GetBatch(requests []BatchRequest) []BatchResult {
+	return c.GetBatchWithContext(context.Background(), requests)
+}
+
+// GetBatchWithContext is GetBatch with a caller-supplied context
+func (c *Client) GetBatchWithContext(ctx context.Context, requests []BatchRequest) []BatchResult {
+	results := make([]BatchResult, len(requests))
+
+	workers := c.BatchConcurrency
+	if workers <= 0 {
+		workers = DefaultBatchConcurrency
+	}
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				resp, err := c.GetWithContext(ctx, requests[i].URL, requests[i].Headers)
+				results[i] = BatchResult{Response: resp, Err: err}
+			}
+		}()
+	}
+
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,64 @@
+package nova402
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetBatch(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	var nonces sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoded := r.Header.Get("X-PAYMENT")
+		if encoded == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		if header, err := DecodePaymentHeader(encoded); err == nil && header.Payload.Authorization != nil {
+			if _, dup := nonces.LoadOrStore(header.Payload.Authorization.Nonce, true); dup {
+				t.Errorf("nonce %s paid twice", header.Payload.Authorization.Nonce)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithBatchConcurrency(3)).WithPrivateKey(testKey)
+	requests := make([]BatchRequest, 20)
+	for i := range requests {
+		requests[i] = BatchRequest{URL: fmt.Sprintf("%s/item/%d", srv.URL, i)}
+	}
+	for i, result := range c.GetBatch(requests) {
+		if result.Err != nil {
+			t.Fatalf("request %d: %v", i, result.Err)
+		}
+		body, _ := io.ReadAll(result.Response.Body)
+		result.Response.Body.Close()
+		if want := fmt.Sprintf("/item/%d", i); string(body) != want {
+			t.Fatalf("result %d is for %s, want %s", i, body, want)
+		}
+	}
+	if max := maxInFlight.Load(); max > 3 {
+		t.Fatalf("%d paid requests in flight at once, want at most 3", max)
+	}
+
+	if results := c.GetBatch(nil); len(results) != 0 {
+		t.Fatalf("GetBatch(nil) = %v, want no results", results)
+	}
+}
@@ -0,0 +1,110 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequirementsCache stores 402 response bodies by resource so repeat requests
+// can pay without the unpaid round trip. Implement it to share the cache
+// across processes, for example in Redis.
+type RequirementsCache interface {
+	// Get returns the cached 402 body for key and whether it was found
+	Get(key string) ([]byte, bool, error)
+	// Set caches a 402 body for key for ttl
+	Set(key string, body []byte, ttl time.Duration) error
+	// Delete removes key from the cache
+	Delete(key string) error
+}
+
+// MemoryRequirementsCache is an in-memory RequirementsCache safe for
+// concurrent use. Expired entries are dropped when read.
+type MemoryRequirementsCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedRequirements
+	now     func() time.Time
+}
+
+type cachedRequirements struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryRequirementsCache creates an empty in-memory requirements cache
+func NewMemoryRequirementsCache() *MemoryRequirementsCache {
+	return &MemoryRequirementsCache{
+		entries: make(map[string]cachedRequirements),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached 402 body for key if it has not expired
+func (m *MemoryRequirementsCache) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, exists := m.entries[key]
+	if !exists {
+		return nil, false, nil
+	}
+	if !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return entry.body, true, nil
+}
+
+// Set caches a 402 body for key for ttl
+func (m *MemoryRequirementsCache) Set(key string, body []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = cachedRequirements{body: body, expiresAt: m.now().Add(ttl)}
+	return nil
+}
+
+// Delete removes key from the cache
+func (m *MemoryRequirementsCache) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// requirementsCacheKey identifies a resource in the requirements cache
+func requirementsCacheKey(method, url string) string {
+	return method + " " + url
+}
+
+// payFromCache pays using cached requirements for the resource. It reports
+// false when there is no usable entry, and invalidates an entry whose payment
+// the server rejects so the caller falls back to a fresh 402.
+func (c *Client) payFromCache(ctx context.Context, method, url string, jsonBody []byte, headers map[string]string) (*PaidResponse, bool, error) {
+	if c.RequirementsCache == nil {
+		return nil, false, nil
+	}
+
+	key := requirementsCacheKey(method, url)
+	body, found, err := c.RequirementsCache.Get(key)
+	if err != nil || !found {
+		return nil, false, nil
+	}
+
+	paid, err := c.handlePaymentRequired(ctx, method, url, jsonBody, headers, body)
+	var paymentErr *PaymentError
+	if errors.As(err, &paymentErr) && paymentErr.StatusCode == http.StatusPaymentRequired {
+		c.RequirementsCache.Delete(key)
+		return nil, false, nil
+	}
+	return paid, true, err
+}
+
+// cacheRequirements stores a fresh 402 body for the resource
+func (c *Client) cacheRequirements(method, url string, body []byte) {
+	if c.RequirementsCache == nil || c.RequirementsCacheTTL <= 0 {
+		return
+	}
+	c.RequirementsCache.Set(requirementsCacheKey(method, url), body, c.RequirementsCacheTTL)
+}
@@ -0,0 +1,60 @@
+package nova402
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequirementsCache(t *testing.T) {
+	var mu sync.Mutex
+	unpaid, rejectNext := 0, false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paid := r.Header.Get("X-PAYMENT") != ""
+		if !paid {
+			unpaid++
+		}
+		if !paid || rejectNext {
+			rejectNext = false
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithRequirementsCache(time.Minute)).WithPrivateKey(testKey)
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get(srv.URL, nil)
+		if err != nil {
+			t.Fatalf("Get %d: %v", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Get %d: status %d, want 200", i, resp.StatusCode)
+		}
+	}
+	mu.Lock()
+	if unpaid != 1 {
+		t.Fatalf("server saw %d unpaid requests, want 1 with cached requirements", unpaid)
+	}
+	// A rejected cached payment drops the entry and pays from a fresh 402
+	rejectNext = true
+	mu.Unlock()
+
+	resp, err := c.Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get after rejection: %v", err)
+	}
+	resp.Body.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if resp.StatusCode != http.StatusOK || unpaid != 2 {
+		t.Fatalf("status %d after %d unpaid requests, want 200 after 2", resp.StatusCode, unpaid)
+	}
+}
@@ -0,0 +1,117 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PaymentCancelFacilitator is implemented by facilitators that can be told
+// to drop a payment they have not yet settled
+type PaymentCancelFacilitator interface {
+	CancelPayment(ctx context.Context, paymentID string) error
+}
+
+// CancelPayment posts to the facilitator's /payments/{id}/cancel endpoint
+func (f *HTTPFacilitator) CancelPayment(ctx context.Context, paymentID string) error {
+	path := "/payments/" + url.PathEscape(paymentID) + "/cancel"
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(f.URL, "/")+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	return f.do(req, path, nil)
+}
+
+// CancelPayment gives up on a payment recorded in the PaymentStore that has
+// not reached a final status. The record is marked expired if its
+// authorization has lapsed and failed otherwise, any spend recorded for it is
+// released from SpendTracker and DailyCaps, a reused "upto" authorization it
+// belongs to is dropped, and the facilitator is asked to cancel an EIP-3009
+// payment, by its nonce, when it implements PaymentCancelFacilitator.
+//
+// Cancellation is best-effort. An EIP-3009 authorization or permit that has
+// already been sent is a valid signature until its ValidBefore or deadline,
+// and whoever holds it can still settle it until then; the facilitator is
+// only asked not to. Failing to reach the facilitator is logged, not
+// returned.
+func (c *Client) CancelPayment(id string) error {
+	return c.CancelPaymentWithContext(context.Background(), id)
+}
+
+// CancelPaymentWithContext is CancelPayment with a caller-supplied context
+func (c *Client) CancelPaymentWithContext(ctx context.Context, id string) error {
+	if c.PaymentStore == nil {
+		return fmt.Errorf("no payment store configured")
+	}
+	record, err := c.PaymentStore.Get(id)
+	if err != nil {
+		return err
+	}
+	if record.Status.IsFinal() {
+		return fmt.Errorf("payment %s is already %s", id, record.Status)
+	}
+
+	status := StatusFailed
+	if !record.ExpiresAt.IsZero() && time.Now().After(record.ExpiresAt) {
+		status = StatusExpired
+	}
+	spend, held := c.heldSpends.take(record.ID)
+
+	previous := record.Status
+	record.Status = status
+	if err := c.PaymentStore.Save(*record); err != nil {
+		if held {
+			c.heldSpends.put(record.ID, spend)
+		}
+		return fmt.Errorf("failed to record cancellation: %w", err)
+	}
+	c.notifyStatusChange(ctx, *record, previous)
+
+	if held {
+		c.releaseSpend(spend.network, spend.amount, spend.recordedAt)
+	}
+	// Only EIP-3009 payments have an ID the facilitator knows; a permit's
+	// nonce is a small per-owner counter that could match anything
+	paymentID, _ := record.Metadata[facilitatorIDKey].(string)
+	if paymentID == "" {
+		return nil
+	}
+	c.uptoAuths.forget(paymentID)
+	c.notifyCancel(ctx, paymentID)
+	return nil
+}
+
+// notifyCancel asks the facilitator to cancel the payment with the given
+// facilitator ID. A facilitator that does not know the payment, or has no
+// cancel endpoint, is not an error.
+func (c *Client) notifyCancel(ctx context.Context, paymentID string) {
+	if c.Facilitator == nil && c.FacilitatorURL == "" {
+		return
+	}
+	facilitator, ok := c.facilitator().(PaymentCancelFacilitator)
+	if !ok {
+		return
+	}
+
+	err := c.waitRateLimit(ctx)
+	if err == nil {
+		err = facilitator.CancelPayment(ctx, paymentID)
+	}
+	var paymentErr *PaymentError
+	if errors.As(err, &paymentErr) {
+		switch paymentErr.StatusCode {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			return
+		}
+	}
+	if err != nil {
+		c.logger().WarnContext(ctx, "x402: facilitator did not cancel payment",
+			slog.String("id", paymentID),
+			slog.String("error", err.Error()))
+	}
+}
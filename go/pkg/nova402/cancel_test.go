@@ -0,0 +1,88 @@
+package nova402
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCancelPayment(t *testing.T) {
+	var mu sync.Mutex
+	var cancelled []string
+	facilitator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/cancel") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		cancelled = append(cancelled, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer facilitator.Close()
+	srv := paidServer(nil)
+	defer srv.Close()
+
+	store := NewMemoryPaymentStore()
+	c := NewClientWithOptions(WithNetwork("base-sepolia"), WithPrivateKey(testKey), WithPaymentStore(store),
+		WithFacilitatorURL(facilitator.URL), WithDailyCap("base-sepolia", big.NewInt(1000)))
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	paid.Response.Body.Close()
+	if total, _ := c.TotalSpent("base-sepolia"); total.String() != "1000" {
+		t.Fatalf("TotalSpent = %v, want 1000", total)
+	}
+
+	if err := c.CancelPayment(paid.PaymentID); err != nil {
+		t.Fatalf("CancelPayment: %v", err)
+	}
+	if payment, _ := store.Get(paid.PaymentID); payment.Status != StatusFailed {
+		t.Fatalf("status after cancelling = %s, want %s", payment.Status, StatusFailed)
+	}
+	// The facilitator knows the payment by its authorization nonce
+	nonce := paid.Payment.Payload.Authorization.Nonce
+	mu.Lock()
+	if len(cancelled) != 1 || cancelled[0] != "/payments/"+nonce+"/cancel" {
+		t.Fatalf("facilitator saw cancellations %q, want one for nonce %s", cancelled, nonce)
+	}
+	mu.Unlock()
+
+	// Cancelling releases the spend, so the cap admits another payment
+	if total, _ := c.TotalSpent("base-sepolia"); total.Sign() != 0 {
+		t.Fatalf("TotalSpent after cancelling = %v, want 0", total)
+	}
+	if err := c.CancelPayment(paid.PaymentID); err == nil {
+		t.Fatal("payment cancelled twice")
+	}
+	paid, err = c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid after the spend was released: %v", err)
+	}
+	paid.Response.Body.Close()
+}
+
+func TestCancelPermitDoesNotNotifyFacilitator(t *testing.T) {
+	var hits atomic.Int32
+	facilitator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer facilitator.Close()
+
+	store := NewMemoryPaymentStore()
+	store.Save(Payment{ID: "permit1", Status: StatusProcessing, Metadata: map[string]interface{}{"spender": "0x1", "nonce": "0"}})
+	c := NewClientWithOptions(WithNetwork("base-sepolia"), WithPaymentStore(store), WithFacilitatorURL(facilitator.URL))
+	if err := c.CancelPayment("permit1"); err != nil {
+		t.Fatalf("CancelPayment: %v", err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Fatalf("permit cancellation reached the facilitator %d times, want 0", n)
+	}
+}
@@ -0,0 +1,55 @@
+package nova402
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// CanonicalJSON encodes v as JSON with object keys sorted at every level, no
+// insignificant whitespace and no HTML escaping, so equal values always
+// produce identical bytes. Numbers are kept exactly as encoding/json renders
+// them.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip through generic values so struct fields are ordered by name
+	// like map keys, keeping numbers as their original literals
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("failed to canonicalize JSON: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(generic); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// MarshalJSON encodes Extra with CanonicalJSON, so its keys are sorted at every
+// level even when it holds structs or other values whose own encoding is not
+// ordered. Requirements echoed into signed or hashed data then always encode
+// to the same bytes.
+func (r PaymentRequirements) MarshalJSON() ([]byte, error) {
+	type alias PaymentRequirements
+	aux := struct {
+		alias
+		Extra json.RawMessage `json:"extra,omitempty"`
+	}{alias: alias(r)}
+	if len(r.Extra) > 0 {
+		extra, err := CanonicalJSON(r.Extra)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode extra: %w", err)
+		}
+		aux.Extra = extra
+	}
+	return json.Marshal(aux)
+}
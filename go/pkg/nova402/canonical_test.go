@@ -0,0 +1,108 @@
+package nova402
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	got, err := CanonicalJSON(map[string]interface{}{
+		"b": 1,
+		"a": []interface{}{map[string]interface{}{"z": "<x>", "y": 1.5}},
+		"c": PaymentPrice{Amount: "1", Symbol: "U"},
+	})
+	if err != nil {
+		t.Fatalf("CanonicalJSON: %v", err)
+	}
+	want, err := CanonicalJSON(map[string]interface{}{
+		"c": PaymentPrice{Symbol: "U", Amount: "1"},
+		"a": []interface{}{map[string]interface{}{"y": 1.5, "z": "<x>"}},
+		"b": 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("equal values encoded differently:\n%s\n%s", got, want)
+	}
+	if !strings.HasPrefix(string(got), `{"a":[{"y":1.5,"z":"<x>"}],"b":1,`) {
+		t.Fatalf("CanonicalJSON = %s, want sorted keys without HTML escaping", got)
+	}
+}
+
+func TestEncodePaymentHeaderIsDeterministic(t *testing.T) {
+	header := PaymentHeader{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base",
+		Payload:     PaymentPayload{Authorization: &EIP3009Authorization{Value: "1000", ValidBefore: 1792139467, Nonce: "0x01"}},
+	}
+	first, err := EncodePaymentHeader(header)
+	if err != nil {
+		t.Fatalf("EncodePaymentHeader: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := EncodePaymentHeader(header)
+		if err != nil {
+			t.Fatalf("EncodePaymentHeader: %v", err)
+		}
+		if again != first {
+			t.Fatalf("encoding %d = %s, want %s", i, again, first)
+		}
+	}
+
+	decoded, err := ParsePaymentHeader(first)
+	if err != nil {
+		t.Fatalf("ParsePaymentHeader: %v", err)
+	}
+	if decoded.Payload.Authorization.ValidBefore != 1792139467 {
+		t.Fatalf("ValidBefore = %d, want 1792139467", decoded.Payload.Authorization.ValidBefore)
+	}
+}
+
+// nestedExtra is a struct value inside Extra whose fields are not in key order
+type nestedExtra struct {
+	Zeta  string            `json:"zeta"`
+	Alpha map[string]string `json:"alpha"`
+}
+
+func TestRequirementsMarshalSortsNestedExtra(t *testing.T) {
+	requirements := PaymentRequirements{Scheme: "exact", Network: "base", Extra: map[string]interface{}{
+		"z": map[string]interface{}{"b": 1, "a": []interface{}{map[string]interface{}{"y": 1, "x": 2}}},
+		"a": nestedExtra{Zeta: "<z>", Alpha: map[string]string{"q": "1", "p": "2"}},
+	}}
+	byValue, err := json.Marshal(requirements)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `"extra":{"a":{"alpha":{"p":"2","q":"1"},"zeta":"\u003cz\u003e"},"z":{"a":[{"x":2,"y":1}],"b":1}}`
+	if !bytes.Contains(byValue, []byte(want)) {
+		t.Fatalf("Marshal = %s, want it to contain %s", byValue, want)
+	}
+
+	byPointer, _ := json.Marshal(&requirements)
+	if !bytes.Equal(byValue, byPointer) {
+		t.Fatalf("Marshal by pointer = %s, want %s", byPointer, byValue)
+	}
+
+	var decoded PaymentRequirements
+	if err := json.Unmarshal(byValue, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	again, _ := json.Marshal(decoded)
+	if !bytes.Equal(byValue, again) {
+		t.Fatalf("re-marshaled requirements = %s, want %s", again, byValue)
+	}
+}
+
+func TestRequirementsMarshalOmitsEmptyExtra(t *testing.T) {
+	encoded, err := json.Marshal(PaymentRequirements{})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if bytes.Contains(encoded, []byte("extra")) {
+		t.Fatalf("Marshal = %s, want no extra field", encoded)
+	}
+}
@@ -3,31 +3,251 @@ package nova402
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/big"
 	"net/http"
+	"strings"
 	"time"
 )
 
 // Client represents an x402 protocol client
 type Client struct {
+	// BaseURL is the service root that relative request URLs, such as
+	// "/resource", are resolved against. Absolute URLs ignore it.
 	BaseURL        string
 	Network        string
 	PrivateKey     string
 	FacilitatorURL string
 	HTTPClient     *http.Client
+
+	// Signer signs EVM payment authorizations. When nil, a LocalSigner for
+	// PrivateKey is used.
+	Signer Signer
+	// SolanaSigner signs Solana payment transactions. When nil, a
+	// LocalSolanaSigner for PrivateKey is used.
+	SolanaSigner SolanaSigner
+	// ContractWallet, when set, is the smart-contract wallet (a Safe or an
+	// account-abstraction account) EVM payments are made from. Authorizations
+	// name it as From and carry the Signer's signature whole in Signature,
+	// for the wallet to validate with EIP-1271.
+	ContractWallet string
+
+	// ComputeUnitPrice is the priority fee, in micro-lamports per compute
+	// unit, added to Solana payment transactions. ComputeUnitLimit caps the
+	// compute units they may use, which also bounds the priority fee at
+	// price*limit. Zero leaves each unset, so no priority fee is paid and the
+	// runtime's default limit applies. The fee is paid in SOL by the fee payer
+	// on top of the token amount. It only helps the transaction land sooner:
+	// a Solana payment stays valid for about 150 blocks (roughly a minute)
+	// from its blockhash whatever MaxTimeoutSeconds allows, so under
+	// congestion a fee is what keeps a payment inside that window.
+	ComputeUnitPrice uint64
+	ComputeUnitLimit uint32
+
+	// Facilitator verifies and settles payments. When nil, an HTTPFacilitator
+	// for FacilitatorURL is used.
+	Facilitator Facilitator
+
+	// DiscoveryURL is the service directory endpoint used by ListServices and
+	// RegisterService. When empty, DefaultDiscoveryPath on FacilitatorURL is
+	// used.
+	DiscoveryURL string
+
+	// FaucetURL is the endpoint RequestFaucet asks for test tokens
+	FaucetURL string
+
+	// RequirementSelector picks which accepted requirement to pay. When nil,
+	// the first requirement matching Network or AllowedNetworks is used.
+	RequirementSelector RequirementSelector
+	// AllowedNetworks are networks besides Network the client will pay on,
+	// for clients that intentionally support several. "*" allows any
+	// network. A requirement on any other network fails with
+	// ErrNetworkMismatch, whichever selector picked it.
+	AllowedNetworks []string
+
+	// PayToAllowlist, when not empty, lists the only payees the client will
+	// pay, and PayToDenylist lists payees it never pays. A requirement whose
+	// PayTo breaks either list fails with ErrPayToNotAllowed. EVM addresses
+	// match whatever their case; Solana addresses are case-sensitive.
+	PayToAllowlist []string
+	PayToDenylist  []string
+
+	// MaxRetries is how many times transient failures of the paid request and
+	// facilitator calls are retried. Zero disables retries.
+	MaxRetries int
+	// RetryBackoff is the base delay between retries, doubled on each attempt.
+	// Defaults to DefaultRetryBackoff.
+	RetryBackoff time.Duration
+
+	// OperationTimeout bounds a whole paid request: the unpaid request and
+	// any Retry-After waits, the RPC calls made while signing, the paid
+	// request with its retries, and reading the response body. When it
+	// fires, in-flight HTTP and RPC calls are cancelled. HTTPClient.Timeout
+	// still bounds each individual HTTP call, and whichever expires first
+	// wins, so set OperationTimeout above it. Zero means no overall limit.
+	OperationTimeout time.Duration
+
+	// ValidityBuffer is how far validAfter is backdated to tolerate clock
+	// skew between the client and the chain. Zero uses DefaultValidityBuffer
+	// seconds.
+	ValidityBuffer time.Duration
+
+	// StrictDecoding rejects 402 responses carrying fields this package does
+	// not know, rather than ignoring them
+	StrictDecoding bool
+
+	// RedirectPolicy controls how paid requests follow redirects. Empty
+	// means RedirectSameOrigin.
+	RedirectPolicy RedirectPolicy
+
+	// PayAmount is the base-unit amount to commit for "upto" requirements. It
+	// must not exceed MaxAmountRequired; empty pays the maximum.
+	PayAmount string
+
+	// CheckBalance makes EVM payments verify the payer's USDC balance covers
+	// the amount before signing
+	CheckBalance bool
+
+	// VerifySignatures makes the client recover the signer of each EVM
+	// authorization it signs, and check each Solana transaction's signature
+	// against the fee payer, failing with ErrInvalidSignature before sending
+	// when they do not match
+	VerifySignatures bool
+
+	// NonceStore, when set, is consulted so no nonce is signed twice for the same payee
+	NonceStore NonceStore
+
+	// NonceFunc, when set, replaces GenerateNonce for EIP-3009 nonces. It
+	// exists so tests can produce reproducible payments; a predictable nonce
+	// in production lets a captured authorization be front-run or collide
+	// with an earlier one, so never set it outside tests.
+	NonceFunc func() (string, error)
+
+	// MaxPaymentAmount caps the base-unit amount paid per request on each
+	// network. Networks without a cap, or with a zero cap, are unlimited.
+	// Caps here and in DailyCaps apply to a network however a requirement
+	// names it, registered name or alias.
+	MaxPaymentAmount map[string]*big.Int
+	// DailyCaps caps the base-unit amount paid on each network within a
+	// window of CapInterval, default DefaultCapInterval. A payment that would
+	// exceed the cap fails with ErrBudgetExceeded until the window resets.
+	DailyCaps   map[string]*big.Int
+	CapInterval time.Duration
+
+	// ApprovalFunc, when set, is asked to approve each payment after it is
+	// signed and before it is sent, with the requirement it pays and its
+	// EIP-3009 authorization. The authorization is zero for Solana and permit
+	// payments. An error aborts the payment and is returned as is, so it can
+	// back a confirmation prompt or a policy check. When nil, every payment
+	// is approved.
+	ApprovalFunc func(PaymentRequirements, EIP3009Authorization) error
+
+	// SettlementMode selects whether Settle goes through the facilitator or
+	// broadcasts directly. Empty means SettlementModeFacilitator.
+	SettlementMode SettlementMode
+	// ReceiptTimeout bounds how long direct settlement waits for the
+	// transaction receipt. Defaults to DefaultReceiptTimeout.
+	ReceiptTimeout time.Duration
+
+	// RateLimit caps paid requests and facilitator calls at this many per
+	// second, allowing bursts of RateBurst. When the budget is spent, calls
+	// wait for a token, failing with ErrRateLimited only if ctx's deadline
+	// would pass first. Zero means unlimited.
+	RateLimit float64
+	RateBurst int
+
+	// SpendTracker totals what the client has paid per network, counting
+	// paid requests the server accepted and payments settled through Settle.
+	// NewClient sets a fresh tracker; nil disables tracking.
+	SpendTracker *SpendTracker
+
+	// Logger receives structured debug events for each step of the payment
+	// flow. Keys and signatures are never logged. When nil, nothing is logged.
+	Logger *slog.Logger
+
+	// Observer receives payment events for metrics. When nil, events are dropped.
+	Observer Observer
+
+	// DryRun signs payments without spending them. Paid requests are not
+	// sent: GetPaid and PostPaid return the signed payment with a synthetic
+	// Settlement and a nil Response, and Get and Post return ErrDryRun.
+	// Settle and SettleDirect return a synthetic successful result; Verify
+	// still calls the facilitator.
+	DryRun bool
+
+	// BatchConcurrency bounds how many GetBatch requests run at once.
+	// Defaults to DefaultBatchConcurrency.
+	BatchConcurrency int
+
+	// RequirementsCache, when set, remembers 402 responses for
+	// RequirementsCacheTTL so repeat requests pay without an unpaid round trip
+	RequirementsCache    RequirementsCache
+	RequirementsCacheTTL time.Duration
+
+	// UptoReuseBudget, when set, makes "upto" payments share one signed
+	// authorization per payee and asset. The authorization is signed for this
+	// base-unit budget, capped by MaxAmountRequired and MaxPaymentAmount, and
+	// reused until its validity window closes or the amounts committed
+	// against it would exceed that value.
+	UptoReuseBudget string
+
+	// PaymentStore, when set, receives a record of every payment sent
+	PaymentStore PaymentStore
+	// OnStatusChange, when set, is called each time a recorded payment
+	// changes status, with the status it moved from. It is called with an
+	// empty previous status when the payment is first recorded as pending.
+	OnStatusChange func(payment Payment, previous PaymentStatus)
+
+	uptoAuths     uptoAuthorizations
+	decimalsCache tokenDecimalsCache
+	rpcTracker    rpcEndpointTracker
+	limiter       rateLimiter
+	capWindow     spendWindow
+	heldSpends    heldSpends
 }
 
-// NewClient creates a new x402 client
-func NewClient(network, facilitatorURL string) *Client {
-	return &Client{
-		Network:        network,
-		FacilitatorURL: facilitatorURL,
-		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// NewClient creates a new x402 client. It is a convenience wrapper over
+// NewClientWithOptions that skips option validation.
+func NewClient(network, facilitatorURL string, opts ...ClientOption) *Client {
+	opts = append([]ClientOption{WithNetwork(network), WithFacilitatorURL(facilitatorURL)}, opts...)
+	return newClient(opts)
+}
+
+// httpClient returns HTTPClient, falling back to http.DefaultClient for
+// clients built without NewClient
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
 	}
+	return http.DefaultClient
+}
+
+// NewClientForEnv creates a client using the facilitator registered in
+// FacilitatorEndpoints for env ("mainnet", "testnet" or "local"). An empty env
+// selects the testnet facilitator for test networks and mainnet otherwise.
+func NewClientForEnv(network, env string, opts ...ClientOption) (*Client, error) {
+	if _, err := GetNetworkConfig(network); err != nil {
+		return nil, err
+	}
+
+	if env == "" {
+		env = "mainnet"
+		if IsTestnet(network) {
+			env = "testnet"
+		}
+	}
+
+	facilitatorURL, exists := FacilitatorEndpoints[env]
+	if !exists {
+		return nil, fmt.Errorf("unknown facilitator environment: %s", env)
+	}
+	return NewClient(network, facilitatorURL, opts...), nil
 }
 
 // WithPrivateKey sets the private key for signing payments
@@ -36,116 +256,652 @@ func (c *Client) WithPrivateKey(privateKey string) *Client {
 	return c
 }
 
-// Get makes a GET request with automatic x402 payment handling
+// Get makes a GET request with automatic x402 payment handling. Like every
+// request method, it resolves a relative url against BaseURL.
 func (c *Client) Get(url string, headers map[string]string) (*http.Response, error) {
-	return c.request("GET", url, nil, headers)
+	return c.GetWithContext(context.Background(), url, headers)
 }
 
 // Post makes a POST request with automatic x402 payment handling
 func (c *Client) Post(url string, body interface{}, headers map[string]string) (*http.Response, error) {
-	return c.request("POST", url, body, headers)
+	return c.PostWithContext(context.Background(), url, body, headers)
 }
 
-func (c *Client) request(method, url string, body interface{}, headers map[string]string) (*http.Response, error) {
-	var bodyReader io.Reader
-	
+// Put makes a PUT request with automatic x402 payment handling
+func (c *Client) Put(url string, body interface{}, headers map[string]string) (*http.Response, error) {
+	return c.PutWithContext(context.Background(), url, body, headers)
+}
+
+// Patch makes a PATCH request with automatic x402 payment handling
+func (c *Client) Patch(url string, body interface{}, headers map[string]string) (*http.Response, error) {
+	return c.PatchWithContext(context.Background(), url, body, headers)
+}
+
+// Delete makes a DELETE request with automatic x402 payment handling
+func (c *Client) Delete(url string, headers map[string]string) (*http.Response, error) {
+	return c.DeleteWithContext(context.Background(), url, headers)
+}
+
+// GetWithContext makes a GET request with automatic x402 payment handling,
+// cancelling the whole payment flow when ctx is done
+func (c *Client) GetWithContext(ctx context.Context, url string, headers map[string]string) (*http.Response, error) {
+	return c.request(ctx, "GET", url, nil, headers)
+}
+
+// PostWithContext makes a POST request with automatic x402 payment handling,
+// cancelling the whole payment flow when ctx is done
+func (c *Client) PostWithContext(ctx context.Context, url string, body interface{}, headers map[string]string) (*http.Response, error) {
+	return c.request(ctx, "POST", url, body, headers)
+}
+
+// PutWithContext makes a PUT request with automatic x402 payment handling,
+// cancelling the whole payment flow when ctx is done
+func (c *Client) PutWithContext(ctx context.Context, url string, body interface{}, headers map[string]string) (*http.Response, error) {
+	return c.request(ctx, "PUT", url, body, headers)
+}
+
+// PatchWithContext makes a PATCH request with automatic x402 payment
+// handling, cancelling the whole payment flow when ctx is done
+func (c *Client) PatchWithContext(ctx context.Context, url string, body interface{}, headers map[string]string) (*http.Response, error) {
+	return c.request(ctx, "PATCH", url, body, headers)
+}
+
+// DeleteWithContext makes a DELETE request with automatic x402 payment
+// handling, cancelling the whole payment flow when ctx is done
+func (c *Client) DeleteWithContext(ctx context.Context, url string, headers map[string]string) (*http.Response, error) {
+	return c.request(ctx, "DELETE", url, nil, headers)
+}
+
+// GetPaid is Get returning what was paid alongside the response. The response
+// body is streamed from the server, not buffered, so large downloads can be
+// copied straight to their destination.
+func (c *Client) GetPaid(ctx context.Context, url string, headers map[string]string) (*PaidResponse, error) {
+	return c.paidRequest(ctx, "GET", url, nil, headers)
+}
+
+// PostPaid is Post returning what was paid alongside the response
+func (c *Client) PostPaid(ctx context.Context, url string, body interface{}, headers map[string]string) (*PaidResponse, error) {
+	return c.paidRequest(ctx, "POST", url, body, headers)
+}
+
+func (c *Client) request(ctx context.Context, method, url string, body interface{}, headers map[string]string) (*http.Response, error) {
+	paid, err := c.paidRequest(ctx, method, url, body, headers)
+	if err != nil {
+		return nil, err
+	}
+	if paid.Response == nil {
+		return nil, ErrDryRun
+	}
+	return paid.Response, nil
+}
+
+func (c *Client) paidRequest(ctx context.Context, method, url string, body interface{}, headers map[string]string) (*PaidResponse, error) {
+	url, err := c.resolveURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if c.OperationTimeout <= 0 {
+		return c.doPaidRequest(ctx, method, url, body, headers)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.OperationTimeout)
+	paid, err := c.doPaidRequest(ctx, method, url, body, headers)
+	if err != nil || paid.Response == nil {
+		cancel()
+		return paid, err
+	}
+	// The deadline covers reading the body, so release it only on Close
+	paid.Response.Body = &cancelOnClose{ReadCloser: paid.Response.Body, cancel: cancel}
+	return paid, nil
+}
+
+// cancelOnClose cancels a request's context once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (c *Client) doPaidRequest(ctx context.Context, method, url string, body interface{}, headers map[string]string) (*PaidResponse, error) {
+	// Marshal the body once so the paid retry can replay the same bytes
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal body: %w", err)
 		}
-		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	if paid, ok, err := c.payFromCache(ctx, method, url, jsonBody, headers); ok {
+		return paid, err
+	}
+
+	resp, paymentBody, err := c.requestRequirements(ctx, method, url, jsonBody, headers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
+	}
+	if resp == nil {
+		c.cacheRequirements(method, url, paymentBody)
+		return c.handlePaymentRequired(ctx, method, url, jsonBody, headers, paymentBody)
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	for k, v := range headers {
-		req.Header.Set(k, v)
+	return &PaidResponse{Response: resp}, nil
+}
+
+// requestRequirements sends the unpaid request. A 402 answer is read and
+// returned as its body; any other response is returned unread. A 402 with a
+// Retry-After header means pricing is temporarily unavailable, so the request
+// is repeated after the indicated delay, up to MaxRetries times.
+func (c *Client) requestRequirements(ctx context.Context, method, url string, jsonBody []byte, headers map[string]string) (*http.Response, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newJSONRequest(ctx, method, url, jsonBody, headers)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("request failed: %w", err)
+		}
+		if resp.StatusCode != http.StatusPaymentRequired {
+			return resp, nil, nil
+		}
+
+		paymentBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read 402 response: %w", err)
+		}
+
+		delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok || attempt >= c.MaxRetries {
+			return nil, paymentBody, nil
+		}
+
+		c.logger().DebugContext(ctx, "x402: payment requirements unavailable, retrying",
+			slog.String("url", url),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay))
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, nil, err
+		}
 	}
+}
 
-	// Make initial request
-	resp, err := c.HTTPClient.Do(req)
+// handlePaymentRequired pays for the resource that answered with paymentBody.
+// Failures are returned as a *PaymentRequiredError carrying that body.
+func (c *Client) handlePaymentRequired(ctx context.Context, method, url string, jsonBody []byte, headers map[string]string, paymentBody []byte) (*PaidResponse, error) {
+	payment, requirements, err := c.preparePayment(ctx, method, url, paymentBody)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, newPaymentRequiredError(paymentBody, err)
 	}
 
-	// Handle 402 Payment Required
-	if resp.StatusCode == 402 {
-		resp.Body.Close()
-		return c.handlePaymentRequired(method, url, body, headers)
+	req, err := newJSONRequest(ctx, method, url, jsonBody, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	newRequest, err := replayable(req)
+	if err != nil {
+		return nil, err
+	}
+	paid, err := c.sendPaid(ctx, newRequest, payment, requirements)
+	if err != nil {
+		return nil, newPaymentRequiredError(paymentBody, err)
+	}
+	return paid, nil
+}
+
+// replayable returns a function producing fresh copies of req for each paid
+// attempt. A one-shot body is read once and replayed through GetBody, so a
+// retry never re-encodes or loses a non-idempotent request body.
+func replayable(req *http.Request) (func() (*http.Request, error), error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
 	}
 
-	return resp, nil
+	return func() (*http.Request, error) {
+		clone := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			clone.Body = body
+		}
+		return clone, nil
+	}, nil
 }
 
-func (c *Client) handlePaymentRequired(method, url string, body interface{}, headers map[string]string) (*http.Response, error) {
-	// Make request to get payment requirements
-	req, err := http.NewRequest(method, url, nil)
+// Quote requests url without payment and returns every requirement the
+// server accepts, so prices can be shown before paying. Nothing is signed. A
+// resource that answers with a 2xx status is free and yields an empty slice.
+func (c *Client) Quote(url string) ([]PaymentRequirements, error) {
+	return c.QuoteWithContext(context.Background(), url)
+}
+
+// QuoteWithContext is Quote with a caller-supplied context
+func (c *Client) QuoteWithContext(ctx context.Context, url string) ([]PaymentRequirements, error) {
+	url, err := c.resolveURL(url)
+	if err != nil {
+		return nil, err
+	}
+	resp, paymentBody, err := c.requestRequirements(ctx, "GET", url, nil, nil)
 	if err != nil {
 		return nil, err
 	}
+	if resp != nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return []PaymentRequirements{}, nil
+		}
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	payment402, err := decodePayment402(paymentBody, c.StrictDecoding)
+	if err != nil {
+		return nil, err
+	}
+	if len(payment402.Accepts) == 0 {
+		return nil, newPaymentRequiredError(paymentBody, ErrNoPaymentRequirements)
+	}
+	accepts := make([]PaymentRequirements, len(payment402.Accepts))
+	for i, requirements := range payment402.Accepts {
+		if requirements.X402Version == 0 {
+			requirements.X402Version = payment402.X402Version
+		}
+		accepts[i] = requirements
+	}
+	return accepts, nil
+}
+
+// PreparePayment requests url without payment and, if the server answers 402,
+// selects a requirement and signs a payment for it without sending anything
+// further. Pass the header to SendWithPayment once it has been approved. It
+// returns ErrPaymentNotRequired if the resource did not ask for payment. For
+// a multi 402 response the header pays every requirement and the first is
+// returned.
+func (c *Client) PreparePayment(url, method string) (PaymentHeader, PaymentRequirements, error) {
+	return c.PreparePaymentWithHeaders(url, method, nil)
+}
+
+// PreparePaymentWithHeaders is PreparePayment with the caller's headers, such
+// as Authorization, sent on the unpaid request
+func (c *Client) PreparePaymentWithHeaders(url, method string, headers map[string]string) (PaymentHeader, PaymentRequirements, error) {
+	url, err := c.resolveURL(url)
+	if err != nil {
+		return PaymentHeader{}, PaymentRequirements{}, err
+	}
+	ctx := context.Background()
+	resp, paymentBody, err := c.requestRequirements(ctx, method, url, nil, headers)
+	if err != nil {
+		return PaymentHeader{}, PaymentRequirements{}, err
+	}
+	if resp != nil {
+		resp.Body.Close()
+		return PaymentHeader{}, PaymentRequirements{}, fmt.Errorf("%w: %s returned status %d", ErrPaymentNotRequired, url, resp.StatusCode)
+	}
+
+	payment, requirements, err := c.preparePayment(ctx, method, url, paymentBody)
+	if err != nil {
+		return PaymentHeader{}, PaymentRequirements{}, newPaymentRequiredError(paymentBody, err)
+	}
+	return *payment, requirements[0], nil
+}
 
-	resp, err := c.HTTPClient.Do(req)
+// SendWithPayment sends req with header as its X-PAYMENT header, retrying
+// transient failures like Get and Post. A 402 answer is returned as a
+// *VerificationError wrapping a *PaymentError.
+func (c *Client) SendWithPayment(req *http.Request, header PaymentHeader) (*http.Response, error) {
+	newRequest, err := replayable(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 402 {
-		return nil, fmt.Errorf("expected 402, got %d", resp.StatusCode)
+	if c.DryRun {
+		return nil, ErrDryRun
+	}
+
+	paid, err := c.sendPaid(req.Context(), newRequest, &header, nil)
+	if err != nil {
+		return nil, err
 	}
+	return paid.Response, nil
+}
 
+// preparePayment parses a 402 body, selects and checks a requirement, and
+// signs a payment for it. A multi 402 response is paid in full, returning
+// every requirement in the order of the header's Payments.
+func (c *Client) preparePayment(ctx context.Context, method, url string, paymentBody []byte) (*PaymentHeader, []PaymentRequirements, error) {
 	// Parse payment requirements
-	var payment402 Payment402Response
-	if err := json.NewDecoder(resp.Body).Decode(&payment402); err != nil {
-		return nil, fmt.Errorf("failed to parse 402 response: %w", err)
+	payment402, err := decodePayment402(paymentBody, c.StrictDecoding)
+	if err != nil {
+		return nil, nil, err
 	}
+	c.observer().OnPaymentRequired(url)
+	c.logger().DebugContext(ctx, "x402: payment required",
+		slog.String("method", method),
+		slog.String("url", url),
+		slog.Int("x402Version", payment402.X402Version),
+		slog.Int("accepts", len(payment402.Accepts)))
 
 	if len(payment402.Accepts) == 0 {
-		return nil, fmt.Errorf("no payment requirements provided")
+		return nil, nil, ErrNoPaymentRequirements
+	}
+
+	// Sign with the version the server advertises, refusing ones we can't speak
+	version := payment402.X402Version
+	if version == 0 {
+		version = X402Version
+	}
+	if !IsSupportedVersion(version) {
+		return nil, nil, fmt.Errorf("%w: server requires version %d", ErrUnsupportedVersion, version)
+	}
+
+	if payment402.Multi {
+		payment, requirements, err := c.prepareMultiPayment(ctx, version, payment402.Accepts)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.logger().DebugContext(ctx, "x402: multi payment signed", slog.Any("payment", *payment))
+		return payment, requirements, nil
+	}
+
+	requirements, err := c.selectRequirement(payment402.Accepts)
+	if err != nil {
+		return nil, nil, err
 	}
+	c.logger().DebugContext(ctx, "x402: requirement selected", requirementAttrs(requirements))
 
-	// Select first payment requirement
-	requirements := payment402.Accepts[0]
+	// Requirements inherit the response version when they don't carry their own
+	if requirements.X402Version == 0 {
+		requirements.X402Version = version
+	}
+	if err := requirements.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkPayTo(requirements.PayTo); err != nil {
+		return nil, nil, err
+	}
+
+	amount, err := c.paymentValue(ctx, requirements)
+	if err != nil {
+		return nil, nil, err
+	}
+	if amount, err = c.drawableValue(requirements, amount); err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkPaymentLimit(requirements.Network, amount); err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkSpendCap(requirements.Network, amount); err != nil {
+		return nil, nil, err
+	}
+
+	if c.CheckBalance && IsEVMNetwork(requirements.Network) {
+		if err := c.checkBalance(ctx, requirements, amount); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	// Create payment header
-	paymentHeader, err := c.createPaymentHeader(requirements)
+	payment, err := c.createPaymentHeader(ctx, requirements)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment: %w", err)
+		return nil, nil, fmt.Errorf("failed to create payment: %w", err)
+	}
+	c.logger().DebugContext(ctx, "x402: payment signed", slog.Any("payment", *payment))
+	if err := c.approvePayment(requirements, payment); err != nil {
+		return nil, nil, err
+	}
+	return payment, []PaymentRequirements{requirements}, nil
+}
+
+// approvePayment passes a signed payment to ApprovalFunc
+func (c *Client) approvePayment(requirements PaymentRequirements, payment *PaymentHeader) error {
+	if c.ApprovalFunc == nil {
+		return nil
+	}
+	var auth EIP3009Authorization
+	if payment.Payload.Authorization != nil {
+		auth = *payment.Payload.Authorization
+	}
+	return c.ApprovalFunc(requirements, auth)
+}
+
+// sendPaid sends requests built by newRequest with the payment attached,
+// retrying transient failures. requirements holds the requirement paid by
+// each of the payment's parts, and is nil when the payment was prepared by
+// the caller.
+func (c *Client) sendPaid(ctx context.Context, newRequest func() (*http.Request, error), payment *PaymentHeader, requirements []PaymentRequirements) (*PaidResponse, error) {
+	paymentHeader, err := EncodePaymentHeader(*payment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payment: %w", err)
+	}
+	paid := &PaidResponse{Payment: payment}
+	if PaymentScheme(payment.Scheme) == SchemeMulti {
+		paid.MultiRequirements = requirements
+	} else {
+		paid.Requirements = partRequirements(requirements, 0)
+	}
+
+	if c.DryRun {
+		var dryRequirements PaymentRequirements
+		if paid.Requirements != nil {
+			dryRequirements = *paid.Requirements
+		}
+		paid.Settlement = dryRunSettlement(*payment, dryRequirements)
+		c.logger().DebugContext(ctx, "x402: dry run, paid request not sent")
+		return paid, nil
+	}
+
+	parts := payment.parts()
+	reservations := make([]*heldSpend, len(parts))
+	releaseAll := func() {
+		for _, reservation := range reservations {
+			c.releaseReservation(reservation)
+		}
+	}
+	for i := range parts {
+		reservation, err := c.reserveSpend(parts[i].Network, c.paidAmount(ctx, &parts[i], partRequirements(requirements, i)))
+		if err != nil {
+			releaseAll()
+			return nil, err
+		}
+		reservations[i] = reservation
+	}
+
+	records := make([]*Payment, len(parts))
+	for i := range parts {
+		record, err := c.recordPayment(ctx, &parts[i], partRequirements(requirements, i))
+		if err != nil {
+			releaseAll()
+			return nil, err
+		}
+		records[i] = record
+		if record != nil && paid.PaymentID == "" {
+			paid.PaymentID = record.ID
+		}
+	}
+	updateAll := func(status PaymentStatus) {
+		for _, record := range records {
+			c.updatePayment(ctx, record, status, nil)
+		}
 	}
 
 	// Retry request with payment
+	var resp *http.Response
+	err = c.withRetry(ctx, func() error {
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-PAYMENT", paymentHeader)
+
+		if err := c.waitRateLimit(ctx); err != nil {
+			return err
+		}
+		resp, err = c.paidHTTPClient().Do(req)
+		if err != nil {
+			return err
+		}
+		if retryableStatus(resp.StatusCode) {
+			return &retryableStatusError{StatusCode: resp.StatusCode}
+		}
+		return nil
+	})
+	if err != nil {
+		updateAll(StatusFailed)
+		releaseAll()
+		// Out of retries: hand the last server response back to the caller
+		var statusErr *retryableStatusError
+		if errors.As(err, &statusErr) {
+			paid.Response = resp
+			return paid, nil
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+
+	// A second 402 means the server rejected the payment we sent
+	if resp.StatusCode == 402 {
+		updateAll(StatusFailed)
+		releaseAll()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		reason := rejectionReason(body)
+		return nil, newVerificationError(&VerificationResult{InvalidReason: &reason}, &PaymentError{
+			StatusCode: resp.StatusCode,
+			Reason:     reason,
+		})
+	}
+
+	paid.Response = resp
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		for i := range parts {
+			c.observer().OnPaymentCompleted(c.paidAmount(ctx, &parts[i], partRequirements(requirements, i)), parts[i].Network)
+		}
+	}
+	if encoded := resp.Header.Get(PaymentResponseHeader); encoded != "" {
+		// The payment went through either way, so a malformed header only
+		// means the settlement details are unavailable
+		if settlement, err := DecodePaymentResponseHeader(encoded); err == nil {
+			paid.Settlement = settlement
+		}
+	}
+	succeeded := resp.StatusCode >= 200 && resp.StatusCode <= 299 && (paid.Settlement == nil || paid.Settlement.Success)
+	for i := range parts {
+		var spent *heldSpend
+		if succeeded {
+			spent = c.commitSpend(ctx, parts[i].Network, c.paidAmount(ctx, &parts[i], partRequirements(requirements, i)), reservations[i])
+		} else {
+			c.releaseReservation(reservations[i])
+		}
+		c.updatePaymentFromResponse(ctx, records[i], resp.StatusCode, paid.Settlement)
+		if spent != nil && records[i] != nil && !records[i].Status.IsFinal() {
+			c.heldSpends.put(records[i].ID, *spent)
+		}
+	}
+	return paid, nil
+}
+
+// updatePaymentFromResponse moves record on from processing once the paid
+// request has been answered. A successful settlement confirms it; a failed
+// one or an error status fails it. Without settlement details the payment
+// stays processing, or expires if its authorization has already lapsed.
+func (c *Client) updatePaymentFromResponse(ctx context.Context, record *Payment, statusCode int, settlement *SettlementResult) {
+	c.updatePayment(ctx, record, StatusProcessing, nil)
+	switch {
+	case statusCode < 200 || statusCode > 299:
+		c.updatePayment(ctx, record, StatusFailed, nil)
+	case settlement != nil && settlement.Success:
+		c.updatePayment(ctx, record, StatusConfirmed, settlement.TxHash)
+	case settlement != nil:
+		c.updatePayment(ctx, record, StatusFailed, nil)
+	}
+}
+
+// paidAmount returns the base-unit amount a payment commits, preferring the
+// signed authorization value. An upto payment commits the amount it draws,
+// since a reused authorization is signed for more.
+func (c *Client) paidAmount(ctx context.Context, payment *PaymentHeader, requirements *PaymentRequirements) string {
+	if requirements != nil && PaymentScheme(requirements.Scheme) == SchemeUpto {
+		if amount, err := c.paymentValue(ctx, *requirements); err == nil {
+			return amount
+		}
+	}
+	if payment.Payload.Authorization != nil {
+		return payment.Payload.Authorization.Value
+	}
+	if payment.Payload.Permit != nil {
+		return payment.Payload.Permit.Value
+	}
+	if requirements != nil {
+		if amount, err := c.paymentValue(ctx, *requirements); err == nil {
+			return amount
+		}
+	}
+	return ""
+}
+
+// clientManagedHeaders are set by the client itself and never taken from the
+// caller's headers
+var clientManagedHeaders = map[string]bool{
+	"Content-Type": true,
+	"X-Payment":    true,
+}
+
+// newJSONRequest builds a request with a JSON content type and the caller's
+// headers. It is used for every request made on the caller's behalf, so
+// credentials such as Authorization reach the server on the unpaid request as
+// well as the paid one.
+func newJSONRequest(ctx context.Context, method, url string, jsonBody []byte, headers map[string]string) (*http.Request, error) {
 	var bodyReader io.Reader
-	if body != nil {
-		jsonBody, _ := json.Marshal(body)
+	if jsonBody != nil {
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err = http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-PAYMENT", paymentHeader)
 	for k, v := range headers {
+		if clientManagedHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
 		req.Header.Set(k, v)
 	}
-
-	return c.HTTPClient.Do(req)
+	return req, nil
 }
 
-func (c *Client) createPaymentHeader(requirements PaymentRequirements) (string, error) {
-	// TODO: Implement actual payment signing
-	// For now, return a placeholder
-	
+func (c *Client) createPaymentHeader(ctx context.Context, requirements PaymentRequirements) (*PaymentHeader, error) {
+	version := requirements.X402Version
+	if version == 0 {
+		version = X402Version
+	}
+
 	payment := PaymentHeader{
-		X402Version: 1,
+		X402Version: version,
 		Scheme:      requirements.Scheme,
 		Network:     requirements.Network,
 		Payload: PaymentPayload{
@@ -153,7 +909,27 @@ func (c *Client) createPaymentHeader(requirements PaymentRequirements) (string,
 		},
 	}
 
-	jsonData, err := json.Marshal(payment)
+	if IsSolanaNetwork(requirements.Network) {
+		payload, err := c.buildSolanaPayload(ctx, requirements)
+		if err != nil {
+			return nil, err
+		}
+		payment.Payload = *payload
+	} else {
+		payload, err := c.evmPayload(ctx, requirements)
+		if err != nil {
+			return nil, err
+		}
+		payment.Payload = payload
+	}
+
+	return &payment, nil
+}
+
+// EncodePaymentHeader encodes a payment header as base64 canonical JSON for the
+// X-PAYMENT header, so the same payment always produces the same header
+func EncodePaymentHeader(header PaymentHeader) (string, error) {
+	jsonData, err := CanonicalJSON(header)
 	if err != nil {
 		return "", err
 	}
@@ -163,7 +939,123 @@ func (c *Client) createPaymentHeader(requirements PaymentRequirements) (string,
 	return encoded, nil
 }
 
+// DecodePaymentHeader decodes a base64 X-PAYMENT header value into a payment header
+func DecodePaymentHeader(encoded string) (*PaymentHeader, error) {
+	jsonData, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payment header: %w", err)
+	}
+
+	var header PaymentHeader
+	if err := json.Unmarshal(jsonData, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse payment header: %w", err)
+	}
+	return &header, nil
+}
+
+// DecodePaymentResponseHeader decodes a base64 X-PAYMENT-RESPONSE header value
+// into the settlement result it reports
+func DecodePaymentResponseHeader(encoded string) (*SettlementResult, error) {
+	jsonData, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payment response header: %w", err)
+	}
+
+	var result SettlementResult
+	if err := json.Unmarshal(jsonData, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse payment response header: %w", err)
+	}
+	return &result, nil
+}
+
+// HeaderLimits bounds what ParsePaymentHeaderWithLimits accepts. Zero fields
+// use MaxPaymentHeaderSize and MaxPaymentSignatures.
+type HeaderLimits struct {
+	// MaxSize is the largest decoded header, in bytes
+	MaxSize int
+	// MaxSignatures is the most Signatures entries a payload may carry
+	MaxSignatures int
+}
+
+// ParsePaymentHeader decodes and validates an inbound X-PAYMENT header for
+// resource servers. Base64 and JSON failures are reported as
+// ErrPaymentHeaderEncoding and ErrPaymentHeaderJSON, headers that decode to
+// more than MaxPaymentHeaderSize bytes as ErrPaymentHeaderTooLarge, and
+// missing fields or more than MaxPaymentSignatures signatures as
+// ErrInvalidPaymentHeader. Each part of a multi payment is checked the same
+// way.
+func ParsePaymentHeader(encoded string) (*PaymentHeader, error) {
+	return ParsePaymentHeaderWithLimits(encoded, HeaderLimits{})
+}
+
+// ParsePaymentHeaderWithLimits is ParsePaymentHeader with the size and
+// signature count bounded by limits. An oversized header is rejected from
+// its encoded length, before anything is decoded or allocated.
+func ParsePaymentHeaderWithLimits(encoded string, limits HeaderLimits) (*PaymentHeader, error) {
+	maxSize := limits.MaxSize
+	if maxSize <= 0 {
+		maxSize = MaxPaymentHeaderSize
+	}
+	maxSignatures := limits.MaxSignatures
+	if maxSignatures <= 0 {
+		maxSignatures = MaxPaymentSignatures
+	}
+
+	encoded = strings.TrimSpace(encoded)
+	if len(encoded) > base64.StdEncoding.EncodedLen(maxSize) {
+		return nil, fmt.Errorf("%w: %d encoded bytes exceeds the %d-byte limit", ErrPaymentHeaderTooLarge, len(encoded), maxSize)
+	}
+
+	jsonData, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentHeaderEncoding, err)
+	}
+	if len(jsonData) > maxSize {
+		return nil, fmt.Errorf("%w: decodes to %d bytes, limit is %d", ErrPaymentHeaderTooLarge, len(jsonData), maxSize)
+	}
+
+	var header PaymentHeader
+	if err := json.Unmarshal(jsonData, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentHeaderJSON, err)
+	}
+
+	if PaymentScheme(header.Scheme) != SchemeMulti {
+		if err := checkPaymentFields(header, maxSignatures); err != nil {
+			return nil, err
+		}
+		return &header, nil
+	}
+
+	if len(header.Payments) == 0 || len(header.Payments) > MaxMultiPayments {
+		return nil, fmt.Errorf("%w: multi payment must bundle 1 to %d payments, got %d", ErrInvalidPaymentHeader, MaxMultiPayments, len(header.Payments))
+	}
+	for i, part := range header.Payments {
+		if PaymentScheme(part.Scheme) == SchemeMulti {
+			return nil, fmt.Errorf("%w: payments[%d] is itself a multi payment", ErrInvalidPaymentHeader, i)
+		}
+		if err := checkPaymentFields(part, maxSignatures); err != nil {
+			return nil, fmt.Errorf("payments[%d]: %w", i, err)
+		}
+	}
+	return &header, nil
+}
+
+// checkPaymentFields checks that a single payment names its scheme and
+// network and carries a payload with at most maxSignatures signatures
+func checkPaymentFields(header PaymentHeader, maxSignatures int) error {
+	switch {
+	case len(header.Payload.Signatures) > maxSignatures:
+		return fmt.Errorf("%w: %d signatures exceeds the limit of %d", ErrInvalidPaymentHeader, len(header.Payload.Signatures), maxSignatures)
+	case header.Scheme == "":
+		return fmt.Errorf("%w: scheme is required", ErrInvalidPaymentHeader)
+	case header.Network == "":
+		return fmt.Errorf("%w: network is required", ErrInvalidPaymentHeader)
+	case header.Payload.Authorization == nil && header.Payload.Permit == nil && header.Payload.Transaction == nil:
+		return fmt.Errorf("%w: payload must contain an authorization, permit or transaction", ErrInvalidPaymentHeader)
+	}
+	return nil
+}
+
 func base64Encode(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
 }
-
@@ -0,0 +1,458 @@
+package nova402
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPaymentHeaderRoundTrip(t *testing.T) {
+	header := PaymentHeader{
+		X402Version: 1,
+		Scheme:      "exact",
+		Network:     "base-sepolia",
+		Payload: PaymentPayload{
+			Authorization: &EIP3009Authorization{
+				From:        "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+				To:          "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+				Value:       "1000",
+				ValidAfter:  1700000000,
+				ValidBefore: 1700000060,
+				Nonce:       "0x0000000000000000000000000000000000000000000000000000000000000001",
+				V:           27,
+				R:           "0x1111111111111111111111111111111111111111111111111111111111111111",
+				S:           "0x2222222222222222222222222222222222222222222222222222222222222222",
+			},
+		},
+	}
+
+	encoded, err := EncodePaymentHeader(header)
+	if err != nil {
+		t.Fatalf("EncodePaymentHeader: %v", err)
+	}
+	decoded, err := DecodePaymentHeader(encoded)
+	if err != nil {
+		t.Fatalf("DecodePaymentHeader: %v", err)
+	}
+	if !reflect.DeepEqual(*decoded, header) {
+		t.Fatalf("round trip = %+v, want %+v", *decoded, header)
+	}
+}
+
+func TestPaidRequestHitsServerTwice(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	resp, err := c.Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Fatalf("Get = %d %q, want 200 \"ok\"", resp.StatusCode, body)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("server saw %d requests, want 2 (the 402 and the paid retry)", n)
+	}
+}
+
+func TestNewClientForEnvDefaultsByNetwork(t *testing.T) {
+	for network, want := range map[string]string{
+		"base-sepolia":   FacilitatorEndpoints["testnet"],
+		"solana-devnet":  FacilitatorEndpoints["testnet"],
+		"base-mainnet":   FacilitatorEndpoints["mainnet"],
+		"solana-mainnet": FacilitatorEndpoints["mainnet"],
+	} {
+		c, err := NewClientForEnv(network, "")
+		if err != nil {
+			t.Fatalf("NewClientForEnv(%q): %v", network, err)
+		}
+		if c.FacilitatorURL != want {
+			t.Fatalf("NewClientForEnv(%q) facilitator = %q, want %q", network, c.FacilitatorURL, want)
+		}
+	}
+
+	c, err := NewClientForEnv("base-sepolia", "local")
+	if err != nil {
+		t.Fatalf("NewClientForEnv local: %v", err)
+	}
+	if c.FacilitatorURL != FacilitatorEndpoints["local"] {
+		t.Fatalf("explicit env facilitator = %q, want %q", c.FacilitatorURL, FacilitatorEndpoints["local"])
+	}
+	if _, err := NewClientForEnv("base-sepolia", "staging"); err == nil {
+		t.Fatal("unknown env accepted")
+	}
+}
+
+func TestParsePaymentHeader(t *testing.T) {
+	tx := "AQID"
+	valid, err := EncodePaymentHeader(PaymentHeader{X402Version: 1, Scheme: "exact", Network: "solana-devnet", Payload: PaymentPayload{Transaction: &tx}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := ParsePaymentHeader(valid)
+	if err != nil {
+		t.Fatalf("ParsePaymentHeader: %v", err)
+	}
+	if header.Network != "solana-devnet" || header.Payload.Transaction == nil || *header.Payload.Transaction != tx {
+		t.Fatalf("ParsePaymentHeader = %+v", header)
+	}
+
+	for name, tc := range map[string]struct {
+		encoded string
+		want    error
+	}{
+		"bad base64":       {"!!!", ErrPaymentHeaderEncoding},
+		"bad JSON":         {base64Encode([]byte("{")), ErrPaymentHeaderJSON},
+		"missing network":  {base64Encode([]byte(`{"scheme":"exact","payload":{"transaction":"AQID"}}`)), ErrInvalidPaymentHeader},
+		"missing payload":  {base64Encode([]byte(`{"scheme":"exact","network":"base"}`)), ErrInvalidPaymentHeader},
+		"oversized header": {strings.Repeat("A", base64.StdEncoding.EncodedLen(MaxPaymentHeaderSize)+4), ErrPaymentHeaderTooLarge},
+	} {
+		if _, err := ParsePaymentHeader(tc.encoded); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: err = %v, want %v", name, err, tc.want)
+		}
+	}
+}
+
+func TestGetPaidReturnsPaymentDetails(t *testing.T) {
+	srv := paidServer(nil)
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	defer paid.Response.Body.Close()
+	if paid.Response.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", paid.Response.StatusCode)
+	}
+	if paid.Payment == nil || paid.Payment.Payload.Authorization == nil || paid.Payment.Payload.Authorization.Value != "1000" {
+		t.Fatalf("Payment = %+v, want the signed authorization for 1000", paid.Payment)
+	}
+	if paid.Requirements == nil || paid.Requirements.MaxAmountRequired != "1000" {
+		t.Fatalf("Requirements = %+v, want the paid requirement", paid.Requirements)
+	}
+}
+
+func TestGetPaidFreeResource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("free"))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	defer paid.Response.Body.Close()
+	if paid.Payment != nil || paid.Requirements != nil {
+		t.Fatalf("free resource reported a payment: %+v", paid)
+	}
+}
+
+func TestGetPaidDecodesSettlementHeader(t *testing.T) {
+	srv := paidServer(func(w http.ResponseWriter) {
+		w.Header().Set(PaymentResponseHeader, base64Encode([]byte(`{"success":true,"txHash":"0xabc","networkId":"base-sepolia"}`)))
+	})
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	paid.Response.Body.Close()
+	if paid.Settlement == nil || !paid.Settlement.Success || paid.Settlement.TxHash == nil || *paid.Settlement.TxHash != "0xabc" {
+		t.Fatalf("Settlement = %+v, want success with tx 0xabc", paid.Settlement)
+	}
+}
+
+func TestGetPaidWithoutSettlementHeader(t *testing.T) {
+	srv := paidServer(nil)
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	paid.Response.Body.Close()
+	if paid.Settlement != nil {
+		t.Fatalf("Settlement = %+v, want nil without an X-PAYMENT-RESPONSE header", paid.Settlement)
+	}
+}
+
+func TestPreparePaymentThenSend(t *testing.T) {
+	var mu sync.Mutex
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = string(body)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	header, requirements, err := c.PreparePayment(srv.URL, "POST")
+	if err != nil {
+		t.Fatalf("PreparePayment: %v", err)
+	}
+	if requirements.MaxAmountRequired != "1000" || header.Payload.Authorization == nil || header.Payload.Authorization.Value != "1000" {
+		t.Fatalf("PreparePayment = %+v, %+v; want an authorization for 1000", header, requirements)
+	}
+
+	req, err := http.NewRequest("POST", srv.URL, strings.NewReader("order"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.SendWithPayment(req, header)
+	if err != nil {
+		t.Fatalf("SendWithPayment: %v", err)
+	}
+	resp.Body.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if resp.StatusCode != http.StatusOK || received != "order" {
+		t.Fatalf("SendWithPayment got %d with body %q, want 200 with \"order\"", resp.StatusCode, received)
+	}
+}
+
+func TestPreparePaymentFreeResource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	if _, _, err := c.PreparePayment(srv.URL, "GET"); !errors.Is(err, ErrPaymentNotRequired) {
+		t.Fatalf("err = %v, want ErrPaymentNotRequired", err)
+	}
+}
+
+func TestGetPaidStreamsLargeBody(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), 8<<20)
+	srv := paidServer(func(w http.ResponseWriter) {
+		w.Write(large)
+	})
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	defer paid.Response.Body.Close()
+	body, err := io.ReadAll(paid.Response.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	// paidServer appends "ok" after the extra writes
+	if len(body) != len(large)+2 || !bytes.Equal(body[:len(large)], large) {
+		t.Fatalf("read %d bytes, want %d", len(body), len(large)+2)
+	}
+}
+
+func TestPostPaidReplaysBodyOnRetry(t *testing.T) {
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if posts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithRetries(2, time.Millisecond)).WithPrivateKey(testKey)
+	paid, err := c.PostPaid(context.Background(), srv.URL, map[string]string{"a": "b"}, nil)
+	if err != nil {
+		t.Fatalf("PostPaid: %v", err)
+	}
+	defer paid.Response.Body.Close()
+	body, _ := io.ReadAll(paid.Response.Body)
+	if string(body) != `{"a":"b"}` {
+		t.Fatalf("retried POST body = %q, want the original {\"a\":\"b\"}", body)
+	}
+	if n := posts.Load(); n != 2 {
+		t.Fatalf("server saw %d paid POSTs, want 2", n)
+	}
+}
+
+func TestPatchAndDeletePay(t *testing.T) {
+	var paidRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		// Fail the first paid request so the PATCH body has to be replayed
+		if paidRequests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + string(body)))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithRetries(1, time.Millisecond)).WithPrivateKey(testKey)
+	resp, err := c.Patch(srv.URL, map[string]int{"x": 1}, nil)
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `PATCH{"x":1}` {
+		t.Fatalf("Patch echoed %q, want PATCH{\"x\":1}", body)
+	}
+
+	resp, err = c.Delete(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "DELETE" {
+		t.Fatalf("Delete echoed %q, want DELETE", body)
+	}
+}
+
+func TestCallerHeadersOnEveryRequest(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, fmt.Sprintf("%s|%s|%t", r.Header.Get("Authorization"), r.Header.Get("Content-Type"), r.Header.Get("X-PAYMENT") != ""))
+		mu.Unlock()
+		if payment := r.Header.Get("X-PAYMENT"); payment == "" || payment == "forged" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+		}
+	}))
+	defer srv.Close()
+
+	// The bearer token reaches every request; the client's own Content-Type
+	// and X-PAYMENT win over the caller's
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	headers := map[string]string{"Authorization": "Bearer tok", "content-type": "text/plain", "x-payment": "forged"}
+	resp, err := c.Post(srv.URL, map[string]int{"x": 1}, headers)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if _, _, err := c.PreparePaymentWithHeaders(srv.URL, "GET", headers); err != nil {
+		t.Fatalf("PreparePaymentWithHeaders: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"Bearer tok|application/json|false", "Bearer tok|application/json|true", "Bearer tok|application/json|false"}
+	if !reflect.DeepEqual(seen, want) {
+		t.Fatalf("server saw %q, want %q", seen, want)
+	}
+}
+
+func TestQuoteDoesNotPay(t *testing.T) {
+	var free atomic.Bool
+	var paid atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") != "" {
+			paid.Add(1)
+		}
+		if free.Load() {
+			return
+		}
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(paid402))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	quote, err := c.Quote(srv.URL)
+	if err != nil || len(quote) != 1 || quote[0].MaxAmountRequired != "1000" || quote[0].X402Version != 1 {
+		t.Fatalf("Quote = %+v, %v; want one requirement for 1000", quote, err)
+	}
+	if n := paid.Load(); n != 0 {
+		t.Fatalf("Quote sent %d payments, want 0", n)
+	}
+
+	free.Store(true)
+	quote, err = c.Quote(srv.URL)
+	if err != nil || quote == nil || len(quote) != 0 {
+		t.Fatalf("Quote of a free resource = %#v, %v; want an empty, non-nil slice", quote, err)
+	}
+}
+
+func TestOversizedPaymentHeaderRejectedBeforeDecoding(t *testing.T) {
+	oversized := strings.Repeat("A", 10<<20)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := ParsePaymentHeader(oversized)
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, ErrPaymentHeaderTooLarge) {
+		t.Fatalf("err = %v, want ErrPaymentHeaderTooLarge", err)
+	}
+	// Decoding the 10MB header would allocate megabytes
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<10 {
+		t.Fatalf("rejecting the header allocated %d bytes, want it rejected before decoding", allocated)
+	}
+
+	// A header of exactly the limit is still decoded
+	atLimit := base64.StdEncoding.EncodeToString(make([]byte, MaxPaymentHeaderSize))
+	if _, err := ParsePaymentHeader(atLimit); errors.Is(err, ErrPaymentHeaderTooLarge) {
+		t.Fatalf("header at the size limit rejected: %v", err)
+	}
+}
+
+func TestParsePaymentHeaderWithLimits(t *testing.T) {
+	signatures := `"` + strings.Repeat(`x","`, 20) + `x"`
+	encoded := base64Encode([]byte(`{"x402Version":1,"scheme":"exact","network":"solana","payload":{"transaction":"AA==","signatures":[` + signatures + `]}}`))
+	if _, err := ParsePaymentHeader(encoded); !errors.Is(err, ErrInvalidPaymentHeader) {
+		t.Fatalf("21 signatures: err = %v, want ErrInvalidPaymentHeader", err)
+	}
+	if _, err := ParsePaymentHeaderWithLimits(encoded, HeaderLimits{MaxSignatures: 30}); err != nil {
+		t.Fatalf("21 signatures under a limit of 30: %v", err)
+	}
+	if _, err := ParsePaymentHeaderWithLimits(encoded, HeaderLimits{MaxSize: 50}); !errors.Is(err, ErrPaymentHeaderTooLarge) {
+		t.Fatalf("header over a 50-byte limit: err = %v, want ErrPaymentHeaderTooLarge", err)
+	}
+}
@@ -0,0 +1,176 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// receiptPollInterval is how often confirmation status is polled
+const receiptPollInterval = 2 * time.Second
+
+// ErrReceiptTimeout is returned when a transaction has not reached the
+// requested confirmations in time. The accompanying result carries the
+// transaction hash, and the block number if it was mined, so the caller can
+// keep watching it.
+var ErrReceiptTimeout = errors.New("timed out waiting for transaction confirmation")
+
+// WaitForConfirmation polls the network until txHash has the requested number
+// of confirmations or timeout elapses. A mined transaction that reverted or
+// failed returns its result with Success false alongside a *SettlementError;
+// one still unconfirmed at the deadline returns ErrReceiptTimeout. Failed
+// polls are retried until then. On Solana, confirmations are counted by the
+// cluster and a finalized transaction satisfies any depth.
+func (c *Client) WaitForConfirmation(network, txHash string, confirmations int, timeout time.Duration) (*SettlementResult, error) {
+	return c.WaitForConfirmationWithContext(context.Background(), network, txHash, confirmations, timeout)
+}
+
+// WaitForConfirmationWithContext is WaitForConfirmation with a
+// caller-supplied context, which also cancels each RPC request in flight
+func (c *Client) WaitForConfirmationWithContext(ctx context.Context, network, txHash string, confirmations int, timeout time.Duration) (*SettlementResult, error) {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return nil, err
+	}
+	if confirmations < 1 {
+		confirmations = 1
+	}
+
+	poll := c.evmConfirmation
+	if config.Type == NetworkTypeSolana {
+		poll = c.solanaConfirmation
+	}
+
+	result := &SettlementResult{
+		TxHash:    &txHash,
+		NetworkID: &network,
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+
+	// RPC failures while polling are usually transient, so they are logged
+	// and polling goes on; the last one is reported if time runs out
+	var lastErr error
+	for {
+		done, err := poll(ctx, config, confirmations, result)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			lastErr = err
+			c.logger().DebugContext(ctx, "x402: confirmation poll failed",
+				slog.String("network", network),
+				slog.String("txHash", txHash),
+				slog.String("error", err.Error()))
+		} else if done {
+			if !result.Success {
+				return result, &SettlementError{Result: result}
+			}
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-deadline.C:
+			if lastErr != nil {
+				return result, fmt.Errorf("%w: %s: last poll failed: %v", ErrReceiptTimeout, txHash, lastErr)
+			}
+			return result, fmt.Errorf("%w: %s", ErrReceiptTimeout, txHash)
+		case <-ticker.C:
+		}
+	}
+}
+
+// evmConfirmation checks the receipt of result.TxHash against the chain head.
+// It reports done once the transaction reverted or is deep enough.
+func (c *Client) evmConfirmation(ctx context.Context, config *NetworkConfig, confirmations int, result *SettlementResult) (bool, error) {
+	var receipt *struct {
+		Status      string `json:"status"`
+		BlockNumber string `json:"blockNumber"`
+	}
+	if err := c.callRPC(ctx, config, "eth_getTransactionReceipt", []interface{}{*result.TxHash}, &receipt); err != nil {
+		return false, fmt.Errorf("failed to get transaction receipt: %w", err)
+	}
+	if receipt == nil {
+		return false, nil
+	}
+
+	block, err := hexutil.DecodeUint64(receipt.BlockNumber)
+	if err != nil {
+		return false, fmt.Errorf("invalid receipt block number %q: %w", receipt.BlockNumber, err)
+	}
+	blockNumber := int64(block)
+	result.BlockNumber = &blockNumber
+
+	if receipt.Status != "0x1" {
+		reason := "transaction reverted"
+		result.Error = &reason
+		return true, nil
+	}
+
+	if confirmations > 1 {
+		var headHex string
+		if err := c.callRPC(ctx, config, "eth_blockNumber", nil, &headHex); err != nil {
+			return false, fmt.Errorf("failed to get block number: %w", err)
+		}
+		head, err := hexutil.DecodeUint64(headHex)
+		if err != nil {
+			return false, fmt.Errorf("invalid block number %q: %w", headHex, err)
+		}
+		if head < block || int(head-block)+1 < confirmations {
+			return false, nil
+		}
+	}
+
+	result.Success = true
+	return true, nil
+}
+
+// solanaConfirmation checks the signature status of result.TxHash. It reports
+// done once the transaction failed, is finalized or has enough confirmations.
+func (c *Client) solanaConfirmation(ctx context.Context, config *NetworkConfig, confirmations int, result *SettlementResult) (bool, error) {
+	var statuses struct {
+		Value []*struct {
+			Slot               int64       `json:"slot"`
+			Confirmations      *int        `json:"confirmations"`
+			ConfirmationStatus string      `json:"confirmationStatus"`
+			Err                interface{} `json:"err"`
+		} `json:"value"`
+	}
+	params := []interface{}{
+		[]string{*result.TxHash},
+		map[string]bool{"searchTransactionHistory": true},
+	}
+	if err := c.callRPC(ctx, config, "getSignatureStatuses", params, &statuses); err != nil {
+		return false, fmt.Errorf("failed to get signature status: %w", err)
+	}
+	if len(statuses.Value) == 0 || statuses.Value[0] == nil {
+		return false, nil
+	}
+
+	status := statuses.Value[0]
+	slot := status.Slot
+	result.BlockNumber = &slot
+
+	if status.Err != nil {
+		reason := fmt.Sprintf("transaction failed: %v", status.Err)
+		result.Error = &reason
+		return true, nil
+	}
+
+	finalized := status.ConfirmationStatus == "finalized"
+	deep := status.Confirmations != nil && *status.Confirmations >= confirmations
+	if !finalized && !deep {
+		return false, nil
+	}
+
+	result.Success = true
+	return true, nil
+}
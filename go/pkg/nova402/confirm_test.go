@@ -0,0 +1,97 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForConfirmationTimesOut(t *testing.T) {
+	srv := rpcServer(`null`)
+	defer srv.Close()
+	registerTestNetwork(t, "confirm-pending", NetworkConfig{ChainID: 7, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+
+	c := NewClient("confirm-pending", "")
+	result, err := c.WaitForConfirmation("confirm-pending", "0xab", 1, 50*time.Millisecond)
+	if !errors.Is(err, ErrReceiptTimeout) {
+		t.Fatalf("err = %v, want ErrReceiptTimeout", err)
+	}
+	if result.Success {
+		t.Fatal("pending transaction reported as successful")
+	}
+}
+
+func TestWaitForConfirmationReverted(t *testing.T) {
+	srv := rpcServer(`{"status":"0x0","blockNumber":"0x2"}`)
+	defer srv.Close()
+	registerTestNetwork(t, "confirm-reverted", NetworkConfig{ChainID: 7, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+
+	c := NewClient("confirm-reverted", "")
+	result, err := c.WaitForConfirmation("confirm-reverted", "0xab", 1, time.Second)
+	var settlementErr *SettlementError
+	if !errors.As(err, &settlementErr) {
+		t.Fatalf("err = %v, want a *SettlementError", err)
+	}
+	if result.Success || result.BlockNumber == nil || *result.BlockNumber != 2 {
+		t.Fatalf("result = %+v, want a failure in block 2", result)
+	}
+}
+
+func TestWaitForConfirmationSolana(t *testing.T) {
+	srv := rpcServer(`{"context":{"slot":5},"value":[{"slot":9,"confirmations":null,"confirmationStatus":"finalized","err":null}]}`)
+	defer srv.Close()
+	registerTestNetwork(t, "confirm-solana", NetworkConfig{ChainID: "confirm", Type: NetworkTypeSolana, RPCUrl: srv.URL})
+
+	c := NewClient("confirm-solana", "")
+	result, err := c.WaitForConfirmation("confirm-solana", "sig", 5, time.Second)
+	if err != nil {
+		t.Fatalf("WaitForConfirmation: %v", err)
+	}
+	if !result.Success || result.BlockNumber == nil || *result.BlockNumber != 9 {
+		t.Fatalf("result = %+v, want finalized success in slot 9", result)
+	}
+}
+
+func TestWaitForConfirmationSurvivesFailedPoll(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"status":"0x1","blockNumber":"0x2"}}`))
+	}))
+	defer srv.Close()
+	registerTestNetwork(t, "confirm-transient", NetworkConfig{ChainID: 7, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+
+	c := NewClient("confirm-transient", "")
+	result, err := c.WaitForConfirmation("confirm-transient", "0xab", 1, 5*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForConfirmation: %v", err)
+	}
+	if !result.Success || calls.Load() < 2 {
+		t.Fatalf("result = %+v after %d polls, want success after a failed poll", result, calls.Load())
+	}
+}
+
+func TestWaitForConfirmationCancelled(t *testing.T) {
+	srv := rpcServer(`null`)
+	defer srv.Close()
+	registerTestNetwork(t, "confirm-cancel", NetworkConfig{ChainID: 7, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c := NewClient("confirm-cancel", "")
+	start := time.Now()
+	_, err := c.WaitForConfirmationWithContext(ctx, "confirm-cancel", "0xab", 1, time.Minute)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("returned after %v, want promptly after cancellation", elapsed)
+	}
+}
@@ -1,19 +1,51 @@
 package nova402
 
-import "encoding/base64"
+import (
+	"fmt"
+	"strings"
+)
 
 // Protocol constants
 const (
-	X402Version             = 1
-	DefaultTimeoutSeconds   = 300
-	DefaultValidityBuffer   = 60
-	DefaultMimeType         = "application/json"
+	X402Version           = 1
+	DefaultTimeoutSeconds = 300
+	DefaultValidityBuffer = 60
+	DefaultMimeType       = "application/json"
+
+	// PaymentResponseHeader carries the base64 JSON settlement result a
+	// resource server returns with a paid response
+	PaymentResponseHeader = "X-PAYMENT-RESPONSE"
+
+	// MaxPaymentHeaderSize is the default bound on the decoded size of an
+	// X-PAYMENT header accepted by ParsePaymentHeader; a signed Solana
+	// transaction fits well within it
+	MaxPaymentHeaderSize = 64 * 1024
+	// MaxPaymentSignatures is the default bound on the Signatures entries of
+	// a payment payload accepted by ParsePaymentHeader
+	MaxPaymentSignatures = 16
+	// MaxMultiPayments bounds the payments a multi payment header may bundle
+	MaxMultiPayments = 16
 )
 
+// SupportedVersions is the set of x402 protocol versions the client can sign
+var SupportedVersions = map[int]bool{
+	X402Version: true,
+}
+
+// IsSupportedVersion reports whether an x402 protocol version is supported
+func IsSupportedVersion(version int) bool {
+	return SupportedVersions[version]
+}
+
 // Supported payment schemes
 var SupportedSchemes = []string{"exact", "upto", "subscription"}
 
-// Network configurations
+// Networks holds the built-in network configurations the registry starts
+// with.
+//
+// Deprecated: the registry copies Networks when the package is initialized,
+// so later changes to it are ignored. Use RegisterNetwork to add networks and
+// NetworkConfigs for a snapshot of the registered ones.
 var Networks = map[string]NetworkConfig{
 	"base-mainnet": {
 		ChainID: 8453,
@@ -113,16 +145,37 @@ var Networks = map[string]NetworkConfig{
 	},
 }
 
-// USDC contract addresses by network
+// USDC contract addresses by network, seeding DefaultTokens. Use RegisterUSDC
+// to change them at runtime.
 var USDCAddresses = map[string]string{
-	"base-mainnet":    "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
-	"base-sepolia":    "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
-	"polygon":         "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174",
-	"bsc":             "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d",
-	"solana-mainnet":  "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
-	"solana-devnet":   "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
+	"base-mainnet":   "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+	"base-sepolia":   "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+	"polygon":        "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174",
+	"bsc":            "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d",
+	"solana-mainnet": "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+	"solana-devnet":  "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
 }
 
+// USDC token decimals by network, seeding DefaultTokens
+var USDCDecimals = map[string]int{
+	"base-mainnet":   6,
+	"base-sepolia":   6,
+	"polygon":        6,
+	"bsc":            18,
+	"solana-mainnet": 6,
+	"solana-devnet":  6,
+}
+
+// Solana program addresses used to build SPL token transfers
+const (
+	SolanaTokenProgramID           = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+	SolanaAssociatedTokenProgramID = "ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL"
+	SolanaComputeBudgetProgramID   = "ComputeBudget111111111111111111111111111111"
+)
+
+// Network name markers identifying test networks
+var testnetMarkers = []string{"sepolia", "devnet", "testnet"}
+
 // Facilitator endpoints
 var FacilitatorEndpoints = map[string]string{
 	"mainnet": "https://facilitator.payai.network",
@@ -132,20 +185,29 @@ var FacilitatorEndpoints = map[string]string{
 
 // GetNetworkConfig returns configuration for a network
 func GetNetworkConfig(network string) (*NetworkConfig, error) {
-	config, exists := Networks[network]
+	config, exists := networkRegistry.network(canonicalNetwork(network))
 	if !exists {
-		return nil, fmt.Errorf("unsupported network: %s", network)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, network)
 	}
 	return &config, nil
 }
 
 // GetUSDCAddress returns USDC address for a network
 func GetUSDCAddress(network string) (string, error) {
-	address, exists := USDCAddresses[network]
+	token, exists := DefaultTokens.Lookup(network, USDCSymbol)
 	if !exists {
-		return "", fmt.Errorf("USDC not configured for network: %s", network)
+		return "", fmt.Errorf("%w: USDC not configured for %s", ErrUnsupportedNetwork, network)
 	}
-	return address, nil
+	return token.Address, nil
+}
+
+// GetUSDCDecimals returns USDC token decimals for a network
+func GetUSDCDecimals(network string) (int, error) {
+	token, exists := DefaultTokens.Lookup(network, USDCSymbol)
+	if !exists {
+		return 0, fmt.Errorf("%w: USDC not configured for %s", ErrUnsupportedNetwork, network)
+	}
+	return token.Decimals, nil
 }
 
 // IsEVMNetwork checks if network is EVM-based
@@ -166,3 +228,12 @@ func IsSolanaNetwork(network string) bool {
 	return config.Type == NetworkTypeSolana
 }
 
+// IsTestnet checks if a network name refers to a test network
+func IsTestnet(network string) bool {
+	for _, marker := range testnetMarkers {
+		if strings.Contains(network, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package nova402
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnknownNetworkErrors(t *testing.T) {
+	if _, err := GetNetworkConfig("not-a-network"); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("GetNetworkConfig error = %v, want ErrUnsupportedNetwork", err)
+	} else if !strings.Contains(err.Error(), "not-a-network") {
+		t.Fatalf("GetNetworkConfig error %q does not name the network", err)
+	}
+
+	if _, err := GetUSDCAddress("not-a-network"); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("GetUSDCAddress error = %v, want ErrUnsupportedNetwork", err)
+	} else if !strings.Contains(err.Error(), "not-a-network") {
+		t.Fatalf("GetUSDCAddress error %q does not name the network", err)
+	}
+}
+
+func TestNetworkAliases(t *testing.T) {
+	want, err := GetNetworkConfig("base-mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"BASE", "base", "base-mainnet", " Base-Mainnet "} {
+		config, err := GetNetworkConfig(name)
+		if err != nil || config.ChainID != want.ChainID {
+			t.Fatalf("GetNetworkConfig(%q) = %+v, %v; want base-mainnet", name, config, err)
+		}
+	}
+	if resolved, err := ResolveNetwork("Solana"); err != nil || resolved != "solana-mainnet" {
+		t.Fatalf("ResolveNetwork(\"Solana\") = %q, %v; want solana-mainnet", resolved, err)
+	}
+	if _, err := ResolveNetwork("not-a-network"); err == nil {
+		t.Fatal("ResolveNetwork accepted an unknown network")
+	}
+
+	alias, err := GetUSDCAddress("base")
+	canonical, _ := GetUSDCAddress("base-mainnet")
+	if err != nil || alias != canonical {
+		t.Fatalf("GetUSDCAddress(\"base\") = %q, %v; want %q", alias, err, canonical)
+	}
+	if _, err := SelectByNetwork("base")([]PaymentRequirements{{Network: "base-mainnet"}}); err != nil {
+		t.Fatalf("SelectByNetwork(\"base\") did not match base-mainnet: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package nova402
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// decimalsSelector is the ERC-20 decimals() function selector
+const decimalsSelector = "313ce567"
+
+// tokenDecimalsCache remembers decimals read from chain. Decimals are
+// immutable, so entries never expire.
+type tokenDecimalsCache struct {
+	mu       sync.RWMutex
+	decimals map[string]int
+}
+
+func (t *tokenDecimalsCache) get(key string) (int, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	decimals, exists := t.decimals[key]
+	return decimals, exists
+}
+
+func (t *tokenDecimalsCache) set(key string, decimals int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.decimals == nil {
+		t.decimals = make(map[string]int)
+	}
+	t.decimals[key] = decimals
+}
+
+// TokenDecimals returns the decimals of the token at asset: from DefaultTokens
+// when registered, otherwise from the ERC-20 decimals() method or the SPL
+// mint account. On-chain results are cached for the client's lifetime.
+func (c *Client) TokenDecimals(network, asset string) (int, error) {
+	return c.TokenDecimalsWithContext(context.Background(), network, asset)
+}
+
+// TokenDecimalsWithContext is TokenDecimals with a caller-supplied context
+func (c *Client) TokenDecimalsWithContext(ctx context.Context, network, asset string) (int, error) {
+	if token, exists := DefaultTokens.LookupAddress(network, asset); exists {
+		return token.Decimals, nil
+	}
+
+	key := network + "|" + asset
+	if strings.HasPrefix(asset, "0x") {
+		key = network + "|" + strings.ToLower(asset)
+	}
+	if decimals, exists := c.decimalsCache.get(key); exists {
+		return decimals, nil
+	}
+
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return 0, err
+	}
+	if !isValidAddress(asset, config.Type) {
+		return 0, fmt.Errorf("invalid %s token address: %s", config.Type, asset)
+	}
+
+	var decimals int
+	switch config.Type {
+	case NetworkTypeEVM:
+		decimals, err = c.erc20Decimals(ctx, config, asset)
+	case NetworkTypeSolana:
+		decimals, err = c.splMintDecimals(ctx, config, asset)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedNetwork, network)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	c.decimalsCache.set(key, decimals)
+	return decimals, nil
+}
+
+// erc20Decimals calls decimals() on an ERC-20 token
+func (c *Client) erc20Decimals(ctx context.Context, config *NetworkConfig, token string) (int, error) {
+	call := map[string]string{"to": token, "data": "0x" + decimalsSelector}
+
+	var result string
+	if err := c.callRPC(ctx, config, "eth_call", []interface{}{call, "latest"}, &result); err != nil {
+		return 0, fmt.Errorf("decimals call failed: %w", err)
+	}
+	raw, err := hexutil.Decode(result)
+	if err != nil || len(raw) != 32 {
+		return 0, fmt.Errorf("invalid decimals result %q from %s", result, token)
+	}
+	// decimals() returns a uint8 padded to a full word
+	for _, b := range raw[:31] {
+		if b != 0 {
+			return 0, fmt.Errorf("invalid decimals result %q from %s", result, token)
+		}
+	}
+	return int(raw[31]), nil
+}
+
+// splMintDecimals reads the decimals of an SPL token mint account
+func (c *Client) splMintDecimals(ctx context.Context, config *NetworkConfig, mint string) (int, error) {
+	var result struct {
+		Value *struct {
+			Data struct {
+				Parsed struct {
+					Type string `json:"type"`
+					Info struct {
+						Decimals *int `json:"decimals"`
+					} `json:"info"`
+				} `json:"parsed"`
+			} `json:"data"`
+		} `json:"value"`
+	}
+	params := []interface{}{mint, map[string]string{"encoding": "jsonParsed"}}
+	if err := c.callRPC(ctx, config, "getAccountInfo", params, &result); err != nil {
+		return 0, fmt.Errorf("getAccountInfo failed: %w", err)
+	}
+	if result.Value == nil {
+		return 0, fmt.Errorf("mint account %s not found", mint)
+	}
+	parsed := result.Value.Data.Parsed
+	if parsed.Type != "mint" || parsed.Info.Decimals == nil {
+		return 0, fmt.Errorf("account %s is not an SPL token mint", mint)
+	}
+	return *parsed.Info.Decimals, nil
+}
+
+// FormatTokenAmount formats a base-unit amount of the token at asset using
+// its decimals, looked up as by TokenDecimals
+func (c *Client) FormatTokenAmount(network, asset, base string) (string, error) {
+	decimals, err := c.TokenDecimals(network, asset)
+	if err != nil {
+		return "", err
+	}
+	formatted := FormatAmount(base, decimals)
+	if formatted == "" {
+		return "", fmt.Errorf("invalid amount %q", base)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,49 @@
+package nova402
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTokenDecimalsCachesERC20(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x0000000000000000000000000000000000000000000000000000000000000012"}`))
+	}))
+	defer srv.Close()
+	registerTestNetwork(t, "dec-net", NetworkConfig{ChainID: 11, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+
+	c := NewClient("dec-net", "")
+	for i := 0; i < 3; i++ {
+		decimals, err := c.TokenDecimals("dec-net", "0x209693Bc6afc0C5328bA36FaF03C514EF312287C")
+		if err != nil || decimals != 18 {
+			t.Fatalf("TokenDecimals = %d, %v; want 18", decimals, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("node saw %d decimals() calls, want 1", n)
+	}
+
+	// The cache key ignores the address's case
+	formatted, err := c.FormatTokenAmount("dec-net", "0x209693bc6afc0c5328ba36faf03c514ef312287c", "1500000000000000000")
+	if err != nil || formatted != "1.5" {
+		t.Fatalf("FormatTokenAmount = %q, %v; want 1.5", formatted, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("node saw %d decimals() calls after formatting, want 1", n)
+	}
+}
+
+func TestTokenDecimalsSPLMint(t *testing.T) {
+	srv := rpcServer(`{"value":{"data":{"parsed":{"type":"mint","info":{"decimals":9}},"program":"spl-token"}}}`)
+	defer srv.Close()
+	registerTestNetwork(t, "dec-sol", NetworkConfig{ChainID: "dec", Type: NetworkTypeSolana, RPCUrl: srv.URL})
+
+	c := NewClient("dec-sol", "")
+	if decimals, err := c.TokenDecimals("dec-sol", "So11111111111111111111111111111111111111112"); err != nil || decimals != 9 {
+		t.Fatalf("TokenDecimals = %d, %v; want 9", decimals, err)
+	}
+}
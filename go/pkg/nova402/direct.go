@@ -0,0 +1,147 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SettlementMode selects who submits payments on-chain
+type SettlementMode string
+
+const (
+	// SettlementModeFacilitator settles through the facilitator's /settle endpoint
+	SettlementModeFacilitator SettlementMode = "facilitator"
+	// SettlementModeDirect broadcasts the EIP-3009 authorization from the
+	// client's own key, paying gas itself
+	SettlementModeDirect SettlementMode = "direct"
+)
+
+// DefaultReceiptTimeout is how long direct settlement waits for a receipt
+const DefaultReceiptTimeout = 2 * time.Minute
+
+// gasLimitBuffer pads estimates by 20% to absorb state changes before inclusion
+const gasLimitBuffer = 120
+
+// SettleDirect submits auth to the network's USDC contract itself, signing the
+// transaction with PrivateKey, and waits for its receipt. Settle in
+// SettlementModeDirect submits to the requirements' asset instead, with the
+// call their authType selects.
+func (c *Client) SettleDirect(network string, auth EIP3009Authorization) (*SettlementResult, error) {
+	return c.SettleDirectWithContext(context.Background(), network, auth)
+}
+
+// SettleDirectWithContext is SettleDirect with a caller-supplied context
+func (c *Client) SettleDirectWithContext(ctx context.Context, network string, auth EIP3009Authorization) (*SettlementResult, error) {
+	token, err := GetUSDCAddress(network)
+	if err != nil {
+		return nil, err
+	}
+	return c.settleDirect(ctx, network, token, AuthTypeTransfer, auth)
+}
+
+// settleDirect submits auth to the token at asset as authType. A
+// receiveWithAuthorization can only be submitted by its payee, so one paying
+// anyone but PrivateKey's address is rejected before it costs gas.
+func (c *Client) settleDirect(ctx context.Context, network, asset, authType string, auth EIP3009Authorization) (*SettlementResult, error) {
+	if c.DryRun {
+		header := PaymentHeader{Network: network, Payload: PaymentPayload{Authorization: &auth}}
+		return dryRunSettlement(header, PaymentRequirements{PayTo: auth.To}), nil
+	}
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return nil, err
+	}
+	if config.Type != NetworkTypeEVM {
+		return nil, fmt.Errorf("%w: direct settlement requires an EVM network, got %s", ErrUnsupportedNetwork, network)
+	}
+	chainID, ok := config.ChainID.(int)
+	if !ok {
+		return nil, fmt.Errorf("network %s has no numeric chain ID", network)
+	}
+
+	// Broadcasting needs a transaction signature, which the typed-data Signer
+	// interface cannot produce
+	if c.PrivateKey == "" {
+		return nil, fmt.Errorf("%w: direct settlement signs transactions with PrivateKey", ErrNoPrivateKey)
+	}
+	key, err := parsePrivateKey(c.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	sender := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	if authType == AuthTypeReceive && !strings.EqualFold(sender, auth.To) {
+		return nil, fmt.Errorf("receiveWithAuthorization must be submitted by the payee %s, not %s", auth.To, sender)
+	}
+
+	data, err := encodeAuthorizationCall(auth, authType)
+	if err != nil {
+		return nil, err
+	}
+
+	gas, err := c.estimateGas(ctx, network, asset, authType, auth)
+	if err != nil {
+		return nil, err
+	}
+	gas = gas * gasLimitBuffer / 100
+
+	var nonceHex, gasPriceHex string
+	if err := c.callRPC(ctx, config, "eth_getTransactionCount", []interface{}{sender, "pending"}, &nonceHex); err != nil {
+		return nil, fmt.Errorf("failed to get account nonce: %w", err)
+	}
+	if err := c.callRPC(ctx, config, "eth_gasPrice", nil, &gasPriceHex); err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	nonce, err := hexutil.DecodeUint64(nonceHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account nonce %q: %w", nonceHex, err)
+	}
+	gasPrice, err := hexutil.DecodeBig(gasPriceHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas price %q: %w", gasPriceHex, err)
+	}
+
+	to := common.HexToAddress(asset)
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Gas:      gas,
+		To:       &to,
+		Value:    new(big.Int),
+		Data:     data,
+	})
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(big.NewInt(int64(chainID))), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction: %w", err)
+	}
+
+	// A node that already knows the transaction received an earlier attempt
+	// to broadcast it, so it is waited for like any other
+	var txHash string
+	err = c.callRPC(ctx, config, "eth_sendRawTransaction", []interface{}{hexutil.Encode(raw)}, &txHash)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.alreadyKnown() {
+		txHash, err = signed.Hash().Hex(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+
+	timeout := c.ReceiptTimeout
+	if timeout <= 0 {
+		timeout = DefaultReceiptTimeout
+	}
+	return c.WaitForConfirmationWithContext(ctx, network, txHash, 1, timeout)
+}
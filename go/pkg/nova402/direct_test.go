@@ -0,0 +1,218 @@
+package nova402
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeNode is a JSON-RPC endpoint that accepts direct settlements: it
+// estimates 100000 gas, reports account nonce 5, and mines every transaction
+// it is sent in block 16
+type fakeNode struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	estimate map[string]string
+	sent     []*types.Transaction
+}
+
+func newFakeNode(t *testing.T) *fakeNode {
+	node := &fakeNode{}
+	node.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string
+			Params []json.RawMessage
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		node.mu.Lock()
+		defer node.mu.Unlock()
+		result := `null`
+		switch req.Method {
+		case "eth_estimateGas":
+			json.Unmarshal(req.Params[0], &node.estimate)
+			result = `"0x186a0"`
+		case "eth_getTransactionCount":
+			result = `"0x5"`
+		case "eth_gasPrice":
+			result = `"0x3b9aca00"`
+		case "eth_sendRawTransaction":
+			var raw string
+			json.Unmarshal(req.Params[0], &raw)
+			tx := new(types.Transaction)
+			if err := tx.UnmarshalBinary(hexutil.MustDecode(raw)); err != nil {
+				t.Errorf("invalid raw transaction: %v", err)
+			}
+			node.sent = append(node.sent, tx)
+			result = `"` + tx.Hash().Hex() + `"`
+		case "eth_getTransactionReceipt":
+			result = `{"status":"0x1","blockNumber":"0x10"}`
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	t.Cleanup(node.Close)
+	return node
+}
+
+// transactions returns the transactions broadcast so far
+func (n *fakeNode) transactions() []*types.Transaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*types.Transaction(nil), n.sent...)
+}
+
+// estimateCall returns the call passed to the last eth_estimateGas
+func (n *fakeNode) estimateCall() map[string]string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.estimate
+}
+
+func TestSettleDirect(t *testing.T) {
+	node := newFakeNode(t)
+	registerTestNetwork(t, "direct-test", NetworkConfig{ChainID: 7, Type: NetworkTypeEVM, RPCUrl: node.URL})
+	usdc := "0xaf88d065e77c8cC2239327C5EDb3A432268e5831"
+	if err := RegisterUSDC("direct-test", usdc, false); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := signedAuthorization(t)
+	c := NewClient("direct-test", "", WithSettlementMode(SettlementModeDirect)).WithPrivateKey(testKey)
+	result, err := c.Settle(PaymentHeader{Payload: PaymentPayload{Authorization: auth}}, PaymentRequirements{Network: "direct-test"})
+	if err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if !result.Success || result.BlockNumber == nil || *result.BlockNumber != 16 {
+		t.Fatalf("result = %+v, want success in block 16", result)
+	}
+
+	sent := node.transactions()
+	if len(sent) != 1 {
+		t.Fatalf("broadcast %d transactions, want 1", len(sent))
+	}
+	tx := sent[0]
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from.Hex() != auth.From || tx.Nonce() != 5 || tx.Gas() != 120000 || tx.ChainId().Int64() != 7 {
+		t.Fatalf("transaction from %s nonce %d gas %d chain %d, want %s nonce 5 gas 120000 chain 7",
+			from.Hex(), tx.Nonce(), tx.Gas(), tx.ChainId(), auth.From)
+	}
+	if tx.To() == nil || tx.To().Hex() != usdc {
+		t.Fatalf("transaction sent to %v, want USDC at %s", tx.To(), usdc)
+	}
+	if result.TxHash == nil || *result.TxHash != tx.Hash().Hex() {
+		t.Fatalf("TxHash = %v, want %s", result.TxHash, tx.Hash().Hex())
+	}
+}
+
+func TestSettleDirectUsesRequirementsAsset(t *testing.T) {
+	node := newFakeNode(t)
+	registerTestNetwork(t, "direct-asset-test", NetworkConfig{ChainID: 7, Type: NetworkTypeEVM, RPCUrl: node.URL})
+
+	auth := signedAuthorization(t)
+	asset := "0x1111111111111111111111111111111111111111"
+	c := NewClient("direct-asset-test", "", WithSettlementMode(SettlementModeDirect), WithContractWallet("0x2222222222222222222222222222222222222222")).WithPrivateKey(testKey)
+	requirements := PaymentRequirements{Network: "direct-asset-test", Asset: asset}
+	if _, err := c.Settle(PaymentHeader{Payload: PaymentPayload{Authorization: auth}}, requirements); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+
+	sent := node.transactions()
+	if len(sent) != 1 || sent[0].To().Hex() != asset {
+		t.Fatalf("broadcast %d transactions, want 1 to %s", len(sent), asset)
+	}
+	// Gas is estimated for the transaction actually sent: to the asset, from
+	// PrivateKey's address rather than the contract wallet
+	call := node.estimateCall()
+	if call["to"] != asset || call["from"] != auth.From {
+		t.Fatalf("estimated %v, want to %s from %s", call, asset, auth.From)
+	}
+}
+
+func TestSettleDirectReceiveRequiresPayee(t *testing.T) {
+	node := newFakeNode(t)
+	registerTestNetwork(t, "direct-receive-test", NetworkConfig{ChainID: 7, Type: NetworkTypeEVM, RPCUrl: node.URL})
+
+	auth := signedAuthorization(t)
+	c := NewClient("direct-receive-test", "", WithSettlementMode(SettlementModeDirect)).WithPrivateKey(testKey)
+	requirements := PaymentRequirements{
+		Network: "direct-receive-test",
+		Asset:   "0x1111111111111111111111111111111111111111",
+		Extra:   map[string]interface{}{"authType": AuthTypeReceive},
+	}
+	_, err := c.Settle(PaymentHeader{Payload: PaymentPayload{Authorization: auth}}, requirements)
+	if err == nil || !strings.Contains(err.Error(), "payee") {
+		t.Fatalf("err = %v, want a payee mismatch", err)
+	}
+	if n := len(node.transactions()); n != 0 {
+		t.Fatalf("broadcast %d transactions, want none", n)
+	}
+}
+
+// unreachable returns a server that fails the test if it receives a request
+func unreachable(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSettleWithFacilitatorOverride(t *testing.T) {
+	var hits atomic.Int32
+	facilitator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte(`{"success":true,"txHash":"0xabc"}`))
+	}))
+	defer facilitator.Close()
+
+	auth := signedAuthorization(t)
+	c := NewClient("base-sepolia", unreachable(t).URL)
+	result, err := c.SettleWithOptions(context.Background(),
+		PaymentHeader{Payload: PaymentPayload{Authorization: auth}},
+		PaymentRequirements{Network: "base-sepolia", MaxAmountRequired: auth.Value},
+		SettleOptions{FacilitatorURL: facilitator.URL})
+	if err != nil || !result.Success {
+		t.Fatalf("SettleWithOptions = %+v, %v; want success", result, err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("override facilitator saw %d requests, want 1", n)
+	}
+
+	if _, err := c.SettleWithOptions(context.Background(), PaymentHeader{}, PaymentRequirements{}, SettleOptions{RPCUrl: "ftp://x"}); err == nil {
+		t.Fatal("non-HTTP RPC override accepted")
+	}
+}
+
+func TestSettleDirectWithRPCOverride(t *testing.T) {
+	node := newFakeNode(t)
+	registerTestNetwork(t, "direct-override-test", NetworkConfig{ChainID: 7, Type: NetworkTypeEVM, RPCUrl: unreachable(t).URL})
+	if err := RegisterUSDC("direct-override-test", "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", false); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := signedAuthorization(t)
+	c := NewClient("direct-override-test", "", WithSettlementMode(SettlementModeDirect)).WithPrivateKey(testKey)
+	result, err := c.SettleWithOptions(context.Background(),
+		PaymentHeader{Payload: PaymentPayload{Authorization: auth}},
+		PaymentRequirements{Network: "direct-override-test"},
+		SettleOptions{RPCUrl: node.URL})
+	if err != nil || !result.Success {
+		t.Fatalf("SettleWithOptions = %+v, %v; want success", result, err)
+	}
+	if sent := node.transactions(); len(sent) != 1 {
+		t.Fatalf("override node received %d transactions, want 1", len(sent))
+	}
+}
@@ -0,0 +1,337 @@
+package nova402
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultDiscoveryPath is where the service directory is found on the
+// facilitator host when DiscoveryURL is unset
+const DefaultDiscoveryPath = "/discovery/services"
+
+// ServicePage is one page of a service directory listing
+type ServicePage struct {
+	Services []Service `json:"services"`
+	// NextCursor fetches the following page, empty on the last one
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// discoveryURL returns DiscoveryURL, defaulting to DefaultDiscoveryPath on
+// the facilitator
+func (c *Client) discoveryURL() (string, error) {
+	if c.DiscoveryURL != "" {
+		return c.DiscoveryURL, nil
+	}
+	if c.FacilitatorURL == "" {
+		return "", fmt.Errorf("no discovery URL or facilitator URL configured")
+	}
+	return strings.TrimRight(c.FacilitatorURL, "/") + DefaultDiscoveryPath, nil
+}
+
+// ListServices returns every service in the directory, following pagination
+// cursors to the end. A non-empty category limits the listing to services in
+// that category.
+func (c *Client) ListServices(category string) ([]Service, error) {
+	return c.ListServicesWithContext(context.Background(), category)
+}
+
+// ListServicesWithContext is ListServices with a caller-supplied context
+func (c *Client) ListServicesWithContext(ctx context.Context, category string) ([]Service, error) {
+	var services []Service
+	seen := make(map[string]bool)
+	cursor := ""
+	for {
+		page, err := c.ListServicesPage(ctx, category, cursor)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, page.Services...)
+		if page.NextCursor == "" {
+			return services, nil
+		}
+		if seen[page.NextCursor] {
+			return nil, fmt.Errorf("service directory repeated cursor %q", page.NextCursor)
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+	}
+}
+
+// ListServicesPage fetches a single page of the service directory, starting
+// at cursor, or at the beginning when cursor is empty. The directory may answer
+// with a ServicePage or with a bare JSON array of services, which is treated
+// as the only page.
+func (c *Client) ListServicesPage(ctx context.Context, category, cursor string) (*ServicePage, error) {
+	endpoint, err := c.discoveryURL()
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discovery URL %q: %w", endpoint, err)
+	}
+	query := u.Query()
+	if category != "" {
+		query.Set("category", category)
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	body, err := c.doDiscovery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var page ServicePage
+	if trimmed := strings.TrimSpace(string(body)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(body, &page.Services)
+	} else {
+		err = json.Unmarshal(body, &page)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service directory: %w", err)
+	}
+
+	// Filter as well, in case the directory ignores the category parameter
+	if category != "" {
+		filtered := page.Services[:0]
+		for _, service := range page.Services {
+			if strings.EqualFold(service.Category, category) {
+				filtered = append(filtered, service)
+			}
+		}
+		page.Services = filtered
+	}
+	return &page, nil
+}
+
+// Validate checks the fields a directory needs to list a service: a name, an
+// absolute http(s) endpoint, a price amount and a registered network
+func (s Service) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidService)
+	}
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("%w: endpoint %q is not an absolute http(s) URL", ErrInvalidService, s.Endpoint)
+	}
+	if strings.TrimSpace(s.Price.Amount) == "" {
+		return fmt.Errorf("%w: price amount is required", ErrInvalidService)
+	}
+	if s.Network == "" {
+		return fmt.Errorf("%w: network is required", ErrInvalidService)
+	}
+	if _, err := GetNetworkConfig(s.Network); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidService, err)
+	}
+	return nil
+}
+
+// ToRequirements turns the service's price into the "exact" requirement a
+// provider answers 402 with for resource, defaulting to the service's
+// Endpoint. Price.Amount is a human readable amount such as "0.01". The token
+// is Price.Asset when it is an address, otherwise the registered token whose
+// symbol is Price.Asset or Price.Symbol, USDC when both are empty; its
+// decimals come from DefaultTokens. The payee is Metadata["payTo"], falling
+// back to Provider when that is an address on the service's network.
+func (s Service) ToRequirements(resource string) (PaymentRequirements, error) {
+	config, err := GetNetworkConfig(s.Network)
+	if err != nil {
+		return PaymentRequirements{}, fmt.Errorf("%w: %w", ErrInvalidService, err)
+	}
+
+	token, err := s.priceToken(config.Type)
+	if err != nil {
+		return PaymentRequirements{}, err
+	}
+	amount, err := ParseAmount(s.Price.Amount, token.Decimals)
+	if err != nil {
+		return PaymentRequirements{}, fmt.Errorf("%w: price: %v", ErrInvalidService, err)
+	}
+
+	payTo, _ := s.Metadata["payTo"].(string)
+	if payTo == "" && isValidAddress(s.Provider, config.Type) {
+		payTo = s.Provider
+	}
+	if payTo == "" {
+		return PaymentRequirements{}, fmt.Errorf("%w: no payTo address in metadata or provider", ErrInvalidService)
+	}
+
+	if resource == "" {
+		resource = s.Endpoint
+	}
+	requirements := PaymentRequirements{
+		X402Version:       X402Version,
+		Scheme:            string(SchemeExact),
+		Network:           s.Network,
+		MaxAmountRequired: amount,
+		Resource:          resource,
+		Description:       s.Description,
+		MimeType:          DefaultMimeType,
+		PayTo:             payTo,
+		MaxTimeoutSeconds: DefaultTimeoutSeconds,
+		Asset:             token.Address,
+	}
+	if err := requirements.Validate(); err != nil {
+		return PaymentRequirements{}, err
+	}
+	return requirements, nil
+}
+
+// priceToken resolves the token the service is priced in through DefaultTokens
+func (s Service) priceToken(networkType NetworkType) (Token, error) {
+	if isValidAddress(s.Price.Asset, networkType) {
+		token, exists := DefaultTokens.LookupAddress(s.Network, s.Price.Asset)
+		if !exists {
+			return Token{}, fmt.Errorf("%w: asset %s is not registered on %s, so its decimals are unknown", ErrInvalidService, s.Price.Asset, s.Network)
+		}
+		return token, nil
+	}
+
+	symbol := s.Price.Asset
+	if symbol == "" {
+		symbol = s.Price.Symbol
+	}
+	if symbol == "" {
+		symbol = USDCSymbol
+	}
+	token, exists := DefaultTokens.Lookup(s.Network, symbol)
+	if !exists {
+		return Token{}, fmt.Errorf("%w: no %s token registered on %s", ErrInvalidService, symbol, s.Network)
+	}
+	return token, nil
+}
+
+// RegisterService publishes s to the service directory and returns the ID
+// the directory assigned it
+func (c *Client) RegisterService(s Service) (string, error) {
+	return c.RegisterServiceWithContext(context.Background(), s)
+}
+
+// RegisterServiceWithContext is RegisterService with a caller-supplied context
+func (c *Client) RegisterServiceWithContext(ctx context.Context, s Service) (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+	endpoint, err := c.discoveryURL()
+	if err != nil {
+		return "", err
+	}
+
+	body, err := c.sendService(ctx, "POST", endpoint, &s)
+	if err != nil {
+		return "", err
+	}
+	var registered Service
+	if err := json.Unmarshal(body, &registered); err != nil {
+		return "", fmt.Errorf("failed to parse service directory response: %w", err)
+	}
+	if registered.ID == "" {
+		return "", fmt.Errorf("service directory assigned no ID")
+	}
+	return registered.ID, nil
+}
+
+// UpdateService replaces the directory listing of the service with s.ID
+func (c *Client) UpdateService(s Service) error {
+	return c.UpdateServiceWithContext(context.Background(), s)
+}
+
+// UpdateServiceWithContext is UpdateService with a caller-supplied context
+func (c *Client) UpdateServiceWithContext(ctx context.Context, s Service) error {
+	if s.ID == "" {
+		return fmt.Errorf("%w: id is required", ErrInvalidService)
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	endpoint, err := c.serviceURL(s.ID)
+	if err != nil {
+		return err
+	}
+	_, err = c.sendService(ctx, "PUT", endpoint, &s)
+	return err
+}
+
+// DeregisterService removes the service with id from the directory
+func (c *Client) DeregisterService(id string) error {
+	return c.DeregisterServiceWithContext(context.Background(), id)
+}
+
+// DeregisterServiceWithContext is DeregisterService with a caller-supplied context
+func (c *Client) DeregisterServiceWithContext(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: id is required", ErrInvalidService)
+	}
+	endpoint, err := c.serviceURL(id)
+	if err != nil {
+		return err
+	}
+	_, err = c.sendService(ctx, "DELETE", endpoint, nil)
+	return err
+}
+
+// serviceURL returns the directory URL of the service with id
+func (c *Client) serviceURL(id string) (string, error) {
+	endpoint, err := c.discoveryURL()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(endpoint, "/") + "/" + url.PathEscape(id), nil
+}
+
+// sendService sends s, if any, to the directory as JSON
+func (c *Client) sendService(ctx context.Context, method, endpoint string, s *Service) ([]byte, error) {
+	var body io.Reader
+	if s != nil {
+		encoded, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal service: %w", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if s != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.doDiscovery(req)
+}
+
+// doDiscovery sends a directory request and returns the body of a successful
+// response
+func (c *Client) doDiscovery(req *http.Request) ([]byte, error) {
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("service directory request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return nil, fmt.Errorf("service directory returned status %d: %s", resp.StatusCode, rejectionReason(errBody))
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service directory response: %w", err)
+	}
+	return body, nil
+}
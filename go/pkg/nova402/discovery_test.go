@@ -0,0 +1,126 @@
+package nova402
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestListServicesPaginates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/discovery/services" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			json.NewEncoder(w).Encode(ServicePage{Services: []Service{{ID: "1", Category: "ai"}, {ID: "2", Category: "data"}}, NextCursor: "c2"})
+		case "c2":
+			json.NewEncoder(w).Encode(ServicePage{Services: []Service{{ID: "3", Category: "AI"}}})
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", srv.URL+"/")
+	all, err := c.ListServices("")
+	if err != nil || len(all) != 3 {
+		t.Fatalf("ListServices = %+v, %v; want 3 services across both pages", all, err)
+	}
+	// Categories match case-insensitively
+	ai, err := c.ListServices("ai")
+	if err != nil || len(ai) != 2 {
+		t.Fatalf("ListServices(\"ai\") = %+v, %v; want 2 services", ai, err)
+	}
+}
+
+func TestListServicesBareArray(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"x"}]`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithDiscoveryURL(srv.URL+"/svc"))
+	services, err := c.ListServices("")
+	if err != nil || len(services) != 1 || services[0].ID != "x" {
+		t.Fatalf("ListServices = %+v, %v; want service x", services, err)
+	}
+}
+
+func TestServiceRegistration(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path+" "+r.Header.Get("Content-Type"))
+		mu.Unlock()
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"svc-1"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", srv.URL)
+	service := Service{Name: "w", Endpoint: "https://x.com/api", Price: PaymentPrice{Amount: "0.01"}, Network: "base-sepolia"}
+	id, err := c.RegisterService(service)
+	if err != nil || id != "svc-1" {
+		t.Fatalf("RegisterService = %q, %v; want svc-1", id, err)
+	}
+	service.ID = id
+	if err := c.UpdateService(service); err != nil {
+		t.Fatalf("UpdateService: %v", err)
+	}
+	if err := c.DeregisterService(id); err != nil {
+		t.Fatalf("DeregisterService: %v", err)
+	}
+	// An incomplete service is rejected before reaching the facilitator
+	if _, err := c.RegisterService(Service{Name: "x"}); !errors.Is(err, ErrInvalidService) {
+		t.Fatalf("incomplete service: err = %v, want ErrInvalidService", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"POST /discovery/services application/json",
+		"PUT /discovery/services/svc-1 application/json",
+		"DELETE /discovery/services/svc-1 ",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("facilitator saw %q, want %q", calls, want)
+	}
+}
+
+func TestServiceToRequirements(t *testing.T) {
+	service := Service{
+		Name:     "w",
+		Endpoint: "https://x.com/api",
+		Price:    PaymentPrice{Amount: "0.015", Symbol: "USDC"},
+		Network:  "base-sepolia",
+		Provider: "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+	}
+	requirements, err := service.ToRequirements("")
+	if err != nil {
+		t.Fatalf("ToRequirements: %v", err)
+	}
+	if requirements.MaxAmountRequired != "15000" || requirements.Resource != service.Endpoint || requirements.Asset == "" {
+		t.Fatalf("ToRequirements = %+v, want 15000 base units of USDC for the endpoint", requirements)
+	}
+
+	usdc, _ := GetUSDCAddress("base-sepolia")
+	service.Price = PaymentPrice{Amount: "1", Asset: usdc}
+	if requirements, err := service.ToRequirements("https://x.com/a"); err != nil || requirements.MaxAmountRequired != "1000000" || requirements.Resource != "https://x.com/a" {
+		t.Fatalf("ToRequirements priced by asset = %+v, %v; want 1000000 for https://x.com/a", requirements, err)
+	}
+
+	// The provider is the payee, so it must be an address
+	service.Provider = "acme"
+	if _, err := service.ToRequirements(""); err == nil {
+		t.Fatal("provider name accepted as a payee")
+	}
+}
@@ -0,0 +1,25 @@
+package nova402
+
+import "strings"
+
+// dryRunTxPrefix marks synthetic transaction hashes produced in dry-run mode
+const dryRunTxPrefix = "0xDRYRUN"
+
+// dryRunSettlement returns the synthetic successful settlement reported in
+// dry-run mode. The hash is derived from the payment so repeated dry runs of
+// the same payment agree.
+func dryRunSettlement(header PaymentHeader, requirements PaymentRequirements) *SettlementResult {
+	txHash := dryRunTxPrefix
+	if key := DefaultIdempotencyKey(header, requirements); key != "" {
+		txHash += strings.ToUpper(key[:66-len(dryRunTxPrefix)])
+	}
+	network := header.Network
+	if network == "" {
+		network = requirements.Network
+	}
+	return &SettlementResult{
+		Success:   true,
+		TxHash:    &txHash,
+		NetworkID: &network,
+	}
+}
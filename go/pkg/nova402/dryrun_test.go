@@ -0,0 +1,55 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDryRunNeverSendsPayment(t *testing.T) {
+	var paid atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") != "" {
+			paid.Add(1)
+		}
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(paid402))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithDryRun(true)).WithPrivateKey(testKey)
+	result, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	if result.Response != nil {
+		t.Fatal("dry run returned a response from the resource server")
+	}
+	if result.Payment == nil || result.Payment.Payload.Authorization == nil {
+		t.Fatal("dry run did not build the payment it would have sent")
+	}
+	settlement := result.Settlement
+	if settlement == nil || !settlement.Success || settlement.TxHash == nil ||
+		!strings.HasPrefix(*settlement.TxHash, "0xDRYRUN") || len(*settlement.TxHash) != 66 {
+		t.Fatalf("Settlement = %+v, want a successful 0xDRYRUN transaction hash", settlement)
+	}
+
+	if _, err := c.Get(srv.URL, nil); !errors.Is(err, ErrDryRun) {
+		t.Fatalf("Get err = %v, want ErrDryRun", err)
+	}
+	if n := paid.Load(); n != 0 {
+		t.Fatalf("server received %d paid requests, want 0", n)
+	}
+
+	settled, err := c.Settle(*result.Payment, *result.Requirements)
+	if err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if *settled.TxHash != *settlement.TxHash {
+		t.Fatalf("Settle tx = %s, want the same fake hash %s", *settled.TxHash, *settlement.TxHash)
+	}
+}
@@ -0,0 +1,240 @@
+package nova402
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// Default EIP-712 domain values of Circle's USDC deployments
+const (
+	DefaultUSDCDomainName    = "USD Coin"
+	DefaultUSDCDomainVersion = "2"
+)
+
+// TokenDomain is the EIP-712 name and version a token contract signs under
+type TokenDomain struct {
+	Name    string
+	Version string
+}
+
+// USDCDomains overrides the USDC EIP-712 domain for networks whose deployment
+// does not use DefaultUSDCDomainName and DefaultUSDCDomainVersion
+var USDCDomains = map[string]TokenDomain{
+	"base-sepolia": {Name: "USDC", Version: "2"},
+}
+
+// tokenDomainRegistry holds EIP-712 domains registered for individual token
+// contracts, keyed by network and lowercased address
+type tokenDomainRegistry struct {
+	mu      sync.RWMutex
+	domains map[string]TokenDomain
+}
+
+var tokenDomains = &tokenDomainRegistry{domains: make(map[string]TokenDomain)}
+
+func tokenDomainKey(network, asset string) string {
+	return canonicalNetwork(network) + "|" + strings.ToLower(asset)
+}
+
+func (r *tokenDomainRegistry) lookup(network, asset string) (TokenDomain, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	domain, exists := r.domains[tokenDomainKey(network, asset)]
+	return domain, exists
+}
+
+func (r *tokenDomainRegistry) register(network, asset string, domain TokenDomain) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.domains[tokenDomainKey(network, asset)] = domain
+}
+
+// RegisterTokenDomain sets the EIP-712 name and version signed under for the
+// token contract at asset on an EVM network, for tokens such as USDT whose
+// domain differs from USDC's. An empty version keeps the default. A domain the
+// server sends in extra["name"] and extra["version"] still takes precedence.
+func RegisterTokenDomain(network, asset, name, version string) error {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return err
+	}
+	if config.Type != NetworkTypeEVM {
+		return fmt.Errorf("%w: token domains apply to EVM networks, got %s", ErrUnsupportedNetwork, network)
+	}
+	if !IsValidEVMAddress(asset) {
+		return fmt.Errorf("invalid token address for %s: %s", network, asset)
+	}
+	if name == "" {
+		return fmt.Errorf("token domain name is required")
+	}
+	tokenDomains.register(network, asset, TokenDomain{Name: name, Version: version})
+	return nil
+}
+
+// BuildEIP712Domain returns the EIP-712 domain for USDC transferWithAuthorization
+// signatures on an EVM network
+func BuildEIP712Domain(network string) (name, version string, chainID int, verifyingContract string, err error) {
+	usdc, err := GetUSDCAddress(network)
+	if err != nil {
+		return "", "", 0, "", err
+	}
+	domain, err := tokenDomain(network, usdc, nil)
+	if err != nil {
+		return "", "", 0, "", err
+	}
+	return domain.Name, domain.Version, domain.ChainID, domain.VerifyingContract, nil
+}
+
+// authorizationDomain returns the EIP-712 domain for signing a payment in the
+// requirements' asset
+func authorizationDomain(requirements PaymentRequirements) (EIP712Domain, error) {
+	asset, err := requirementsAsset(requirements)
+	if err != nil {
+		return EIP712Domain{}, err
+	}
+	return tokenDomain(requirements.Network, asset, requirements.Extra)
+}
+
+// tokenDomain builds the EIP-712 domain of the token at asset. The name and
+// version come from extra["name"] and extra["version"] when the server
+// provides them, then from RegisterTokenDomain, then from USDCDomains for
+// USDC, then from the USDC defaults.
+func tokenDomain(network, asset string, extra map[string]interface{}) (EIP712Domain, error) {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return EIP712Domain{}, err
+	}
+	if config.Type != NetworkTypeEVM {
+		return EIP712Domain{}, fmt.Errorf("%w: EIP-712 domain requires an EVM network, got %s", ErrUnsupportedNetwork, network)
+	}
+
+	chainID, ok := config.ChainID.(int)
+	if !ok {
+		return EIP712Domain{}, fmt.Errorf("network %s has no numeric chain ID", network)
+	}
+
+	domain := EIP712Domain{
+		Name:              DefaultUSDCDomainName,
+		Version:           DefaultUSDCDomainVersion,
+		ChainID:           chainID,
+		VerifyingContract: asset,
+	}
+	if usdc, exists := DefaultTokens.Lookup(network, USDCSymbol); exists && sameAddress(usdc.Address, asset) {
+		if override, exists := USDCDomains[canonicalNetwork(network)]; exists {
+			if override.Name != "" {
+				domain.Name = override.Name
+			}
+			if override.Version != "" {
+				domain.Version = override.Version
+			}
+		}
+	}
+	if registered, exists := tokenDomains.lookup(network, asset); exists {
+		domain.Name = registered.Name
+		if registered.Version != "" {
+			domain.Version = registered.Version
+		}
+	}
+	if name, ok := extra["name"].(string); ok && name != "" {
+		domain.Name = name
+	}
+	if version, ok := extra["version"].(string); ok && version != "" {
+		domain.Version = version
+	}
+	return domain, nil
+}
+
+// EIP-3009 authorization variants, selected by the "authType" key of
+// PaymentRequirements.Extra. transferWithAuthorization, the default, can be
+// submitted by anyone holding the signature. receiveWithAuthorization can only
+// be submitted by the payee, which protects against front-running when payTo
+// is a contract that pulls the funds itself. Circle's USDC and EURC
+// deployments on every supported EVM network implement both; a server should
+// ask for the receive variant only when its payTo or token requires it.
+// SettleDirect submits transferWithAuthorization, while Settle in
+// SettlementModeDirect submits the variant the requirements ask for.
+const (
+	AuthTypeTransfer = "transferWithAuthorization"
+	AuthTypeReceive  = "receiveWithAuthorization"
+)
+
+// authorizationFields is the EIP-3009 authorization struct, shared by the
+// TransferWithAuthorization and ReceiveWithAuthorization types
+var authorizationFields = []TypedDataField{
+	{Name: "from", Type: "address"},
+	{Name: "to", Type: "address"},
+	{Name: "value", Type: "uint256"},
+	{Name: "validAfter", Type: "uint256"},
+	{Name: "validBefore", Type: "uint256"},
+	{Name: "nonce", Type: "bytes32"},
+}
+
+// authorizationPrimaryTypes maps each authorization variant to its EIP-712 type name
+var authorizationPrimaryTypes = map[string]string{
+	AuthTypeTransfer: "TransferWithAuthorization",
+	AuthTypeReceive:  "ReceiveWithAuthorization",
+}
+
+// authorizationType returns the variant requested by extra["authType"],
+// defaulting to AuthTypeTransfer. It may also be AuthTypePermit.
+func authorizationType(requirements PaymentRequirements) (string, error) {
+	authType, _ := requirements.Extra["authType"].(string)
+	if authType == "" {
+		return AuthTypeTransfer, nil
+	}
+	if _, exists := authorizationPrimaryTypes[authType]; !exists && authType != AuthTypePermit {
+		return "", fmt.Errorf("%w: unsupported authType %q", ErrInvalidRequirements, authType)
+	}
+	return authType, nil
+}
+
+// authorizationTypedData returns auth as typed data of the given variant
+func authorizationTypedData(auth *EIP3009Authorization, authType string) TypedData {
+	primaryType := authorizationPrimaryTypes[authType]
+	value, _ := new(big.Int).SetString(auth.Value, 10)
+	return TypedData{
+		PrimaryType: primaryType,
+		Types: map[string][]TypedDataField{
+			primaryType: authorizationFields,
+		},
+		Message: map[string]interface{}{
+			"from":        auth.From,
+			"to":          auth.To,
+			"value":       value,
+			"validAfter":  big.NewInt(auth.ValidAfter),
+			"validBefore": big.NewInt(auth.ValidBefore),
+			"nonce":       auth.Nonce,
+		},
+	}
+}
+
+// AuthorizationDigest returns the 32-byte EIP-712 digest a payer signs for a
+// USDC transferWithAuthorization on an EVM network. Signing it with any
+// secp256k1 key holder, such as an HSM that only signs raw hashes, gives the
+// v, r and s of the authorization.
+func AuthorizationDigest(auth EIP3009Authorization, network string) ([]byte, error) {
+	return RequirementsAuthorizationDigest(auth, PaymentRequirements{Network: network})
+}
+
+// RequirementsAuthorizationDigest is AuthorizationDigest for auth paying
+// requirements: in the domain of their asset, USDC by default, as the
+// authorization variant their authType selects
+func RequirementsAuthorizationDigest(auth EIP3009Authorization, requirements PaymentRequirements) ([]byte, error) {
+	domain, err := authorizationDomain(requirements)
+	if err != nil {
+		return nil, err
+	}
+	authType, err := authorizationType(requirements)
+	if err != nil {
+		return nil, err
+	}
+	if authType == AuthTypePermit {
+		return nil, fmt.Errorf("%w: permit requirements take an EIP-2612 permit, not an EIP-3009 authorization", ErrInvalidRequirements)
+	}
+	if _, ok := new(big.Int).SetString(auth.Value, 10); !ok {
+		return nil, fmt.Errorf("invalid authorization value %q", auth.Value)
+	}
+	return HashTypedData(domain, authorizationTypedData(&auth, authType))
+}
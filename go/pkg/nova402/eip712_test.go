@@ -0,0 +1,168 @@
+package nova402
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// recoverSigner returns the address that produced the v, r, s signature of digest
+func recoverSigner(t *testing.T, digest []byte, v int, r, s string) string {
+	t.Helper()
+	sig := append(hexutil.MustDecode(r), hexutil.MustDecode(s)...)
+	sig = append(sig, byte(v-27))
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		t.Fatalf("SigToPub: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub).Hex()
+}
+
+func TestReceiveWithAuthorization(t *testing.T) {
+	requirements := testRequirements()
+	requirements.Extra = map[string]interface{}{"authType": AuthTypeReceive}
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	auth, err := c.buildAuthorization(context.Background(), requirements, 0, time.Now().Unix()+60)
+	if err != nil {
+		t.Fatalf("buildAuthorization: %v", err)
+	}
+
+	digest, err := RequirementsAuthorizationDigest(*auth, requirements)
+	if err != nil {
+		t.Fatalf("RequirementsAuthorizationDigest: %v", err)
+	}
+	if got := recoverSigner(t, digest, auth.V, auth.R, auth.S); got != auth.From {
+		t.Fatalf("receive signature recovers to %s, want %s", got, auth.From)
+	}
+	// The variants have different type hashes, so a receive authorization
+	// must not pass as a transfer
+	if ok, _ := VerifyAuthorizationSignature(*auth, "base-sepolia"); ok {
+		t.Fatal("receiveWithAuthorization signature verified as transferWithAuthorization")
+	}
+}
+
+func TestUnknownAuthTypeRejected(t *testing.T) {
+	requirements := testRequirements()
+	requirements.Extra = map[string]interface{}{"authType": "bogus"}
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	if _, err := c.buildAuthorization(context.Background(), requirements, 0, time.Now().Unix()+60); !errors.Is(err, ErrInvalidRequirements) {
+		t.Fatalf("err = %v, want ErrInvalidRequirements", err)
+	}
+}
+
+func TestRegisterTokenDomain(t *testing.T) {
+	const asset = "0x1111111111111111111111111111111111111111"
+	t.Cleanup(func() {
+		tokenDomains.mu.Lock()
+		delete(tokenDomains.domains, tokenDomainKey("base-mainnet", asset))
+		tokenDomains.mu.Unlock()
+	})
+
+	usdc, err := GetUSDCAddress("base-mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	usdcDomain, _ := tokenDomain("base-mainnet", usdc, nil)
+	// An unregistered token signs under USDC's domain name
+	if unregistered, _ := tokenDomain("base-mainnet", asset, nil); unregistered.Name != usdcDomain.Name {
+		t.Fatalf("unregistered token domain name = %q, want USDC's %q", unregistered.Name, usdcDomain.Name)
+	}
+
+	if err := RegisterTokenDomain("base-mainnet", asset, "Tether USD", "1"); err != nil {
+		t.Fatalf("RegisterTokenDomain: %v", err)
+	}
+	registered, err := tokenDomain("base-mainnet", asset, nil)
+	if err != nil || registered.Name != "Tether USD" || registered.Version != "1" {
+		t.Fatalf("registered token domain = %+v, %v; want Tether USD version 1", registered, err)
+	}
+
+	// The same message under the USDC name and the registered name must hash
+	// differently, or a signature for one token would be valid for the other
+	auth := &EIP3009Authorization{From: asset, To: asset, Value: "1", Nonce: "0x" + strings.Repeat("00", 32)}
+	message := authorizationTypedData(auth, AuthTypeTransfer)
+	usdcDomain.VerifyingContract = asset
+	usdcDigest, _ := HashTypedData(usdcDomain, message)
+	tokenDigest, err := HashTypedData(registered, message)
+	if err != nil {
+		t.Fatalf("HashTypedData: %v", err)
+	}
+	if bytes.Equal(usdcDigest, tokenDigest) {
+		t.Fatal("registered token name does not change the digest")
+	}
+
+	// The server's extra still takes precedence
+	if fromExtra, _ := tokenDomain("base-mainnet", asset, map[string]interface{}{"name": "X"}); fromExtra.Name != "X" || fromExtra.Version != "1" {
+		t.Fatalf("domain with extra name = %+v, want X version 1", fromExtra)
+	}
+}
+
+func TestRegisterTokenDomainRequiresEVM(t *testing.T) {
+	if err := RegisterTokenDomain("solana-devnet", "0x1111111111111111111111111111111111111111", "a", ""); err == nil {
+		t.Fatal("token domain registered for a Solana network")
+	}
+}
+
+func TestAuthorizationDigestKnownVector(t *testing.T) {
+	// The exact-scheme EVM example from the x402 specification: a USDC
+	// authorization on base-sepolia and the signature its payer published
+	auth := EIP3009Authorization{
+		From:        "0x857b06519E91e3A54538791bDbb0E22373e36b66",
+		To:          "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		Value:       "10000",
+		ValidAfter:  1740672089,
+		ValidBefore: 1740672154,
+		Nonce:       "0xf3746613c2d920b5fdabc0856f2aeb2d4f88ee6037b8cc5d04a71a4462f13480",
+	}
+	const signature = "0x2d6a7588d6acca505cbf0d9a4a227e0c52c6c34008c8e8986a1283259764173608a2ce6496642e377d6da8dbbf5836e9bd15092f9ecab05ded3d6293af148b571c"
+
+	digest, err := AuthorizationDigest(auth, "base-sepolia")
+	if err != nil {
+		t.Fatalf("AuthorizationDigest: %v", err)
+	}
+	if len(digest) != 32 {
+		t.Fatalf("digest is %d bytes, want 32", len(digest))
+	}
+	sig := hexutil.MustDecode(signature)
+	if got := recoverSigner(t, digest, int(sig[64]), hexutil.Encode(sig[:32]), hexutil.Encode(sig[32:64])); got != auth.From {
+		t.Fatalf("published signature recovers to %s under the digest, want the payer %s", got, auth.From)
+	}
+
+	if _, err := AuthorizationDigest(auth, "solana-devnet"); err == nil {
+		t.Fatal("AuthorizationDigest accepted a Solana network")
+	}
+}
+
+func TestAuthorizationDigestRegisteredToken(t *testing.T) {
+	const asset = "0x3333333333333333333333333333333333333333"
+	t.Cleanup(func() {
+		tokenDomains.mu.Lock()
+		delete(tokenDomains.domains, tokenDomainKey("base-sepolia", asset))
+		tokenDomains.mu.Unlock()
+	})
+	if err := RegisterTokenDomain("base-sepolia", asset, "Tether USD", "1"); err != nil {
+		t.Fatalf("RegisterTokenDomain: %v", err)
+	}
+
+	requirements := testRequirements()
+	requirements.Asset = asset
+	requirements.Extra = map[string]interface{}{"authType": AuthTypeReceive}
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	validAfter, validBefore := requirements.ValidityWindow(time.Now())
+	auth, err := c.buildAuthorization(context.Background(), requirements, validAfter, validBefore)
+	if err != nil {
+		t.Fatalf("buildAuthorization: %v", err)
+	}
+	digest, err := RequirementsAuthorizationDigest(*auth, requirements)
+	if err != nil {
+		t.Fatalf("RequirementsAuthorizationDigest: %v", err)
+	}
+	if got := recoverSigner(t, digest, auth.V, auth.R, auth.S); got != auth.From {
+		t.Fatalf("digest recovers to %s, want the signer %s", got, auth.From)
+	}
+}
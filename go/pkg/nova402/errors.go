@@ -0,0 +1,226 @@
+package nova402
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors for client-side failures. Use errors.Is to match them.
+var (
+	ErrUnsupportedNetwork     = errors.New("unsupported network")
+	ErrNoPaymentRequirements  = errors.New("no payment requirements provided")
+	ErrNoPrivateKey           = errors.New("no private key configured")
+	ErrInvalidPrivateKey      = errors.New("invalid private key")
+	ErrInvalidRequirements    = errors.New("invalid payment requirements")
+	ErrInsufficientFunds      = errors.New("insufficient funds")
+	ErrNotImplemented         = errors.New("not implemented")
+	ErrPeriodAlreadyPaid      = errors.New("subscription period already paid")
+	ErrSubscriptionEnded      = errors.New("subscription has ended")
+	ErrNetworkExists          = errors.New("network already registered")
+	ErrTokenExists            = errors.New("token already registered")
+	ErrUnsupportedVersion     = errors.New("unsupported x402 version")
+	ErrInvalidPaymentHeader   = errors.New("invalid payment header")
+	ErrPaymentHeaderEncoding  = errors.New("payment header is not valid base64")
+	ErrPaymentHeaderJSON      = errors.New("payment header is not valid JSON")
+	ErrPaymentHeaderTooLarge  = errors.New("payment header too large")
+	ErrAmountExceedsLimit     = errors.New("payment amount exceeds limit")
+	ErrStatusTimeout          = errors.New("timed out waiting for payment status")
+	ErrPaymentNotRequired     = errors.New("resource did not require payment")
+	ErrDryRun                 = errors.New("dry run: paid request not sent")
+	ErrInvalidSignature       = errors.New("invalid authorization signature")
+	ErrPaymentNotFound        = errors.New("payment not found")
+	ErrUnsupportedToken       = errors.New("token supports neither EIP-3009 nor EIP-2612 permit")
+	ErrInvalidPaymentRequired = errors.New("invalid 402 response")
+	ErrCrossOriginRedirect    = errors.New("paid request redirected to another origin")
+	ErrInvalidAmount          = errors.New("invalid payment amount")
+	ErrRateLimited            = errors.New("client rate limit exceeded")
+	ErrNoBaseURL              = errors.New("no base URL configured")
+	ErrBudgetExceeded         = errors.New("spend cap exceeded")
+	ErrInvalidService         = errors.New("invalid service")
+	ErrNetworkMismatch        = errors.New("payment network mismatch")
+	ErrPayToNotAllowed        = errors.New("payee address not allowed")
+)
+
+// PaymentError is a server-side rejection of a payment, either by the resource
+// server answering the paid request or by the facilitator. Use errors.As to
+// inspect it.
+type PaymentError struct {
+	// StatusCode is the HTTP status returned by the rejecting party
+	StatusCode int
+	// Reason is the rejection message reported by the server or facilitator
+	Reason string
+	// Endpoint is the facilitator path that failed, empty for the resource server
+	Endpoint string
+}
+
+func (e *PaymentError) Error() string {
+	if e.Endpoint != "" {
+		return fmt.Sprintf("facilitator %s returned status %d: %s", e.Endpoint, e.StatusCode, e.Reason)
+	}
+	return fmt.Sprintf("payment rejected with status %d: %s", e.StatusCode, e.Reason)
+}
+
+// PaymentRequiredError reports a resource that answered 402 and could not be
+// paid. It keeps the server's 402 response, whose Error and Accepts often
+// explain what the client would need to pay, and wraps the failure that
+// stopped the payment. Use errors.As to inspect it.
+type PaymentRequiredError struct {
+	// Response is the decoded 402 body, nil when it was not valid JSON
+	Response *Payment402Response
+	// Body is the raw 402 body
+	Body []byte
+	// Err is why the payment failed
+	Err error
+}
+
+// newPaymentRequiredError wraps err with the 402 body it was answering
+func newPaymentRequiredError(body []byte, err error) *PaymentRequiredError {
+	paymentErr := &PaymentRequiredError{Body: body, Err: err}
+	var response Payment402Response
+	if json.Unmarshal(body, &response) == nil {
+		paymentErr.Response = &response
+	}
+	return paymentErr
+}
+
+func (e *PaymentRequiredError) Error() string {
+	msg := e.Err.Error()
+	if e.Response != nil && e.Response.Error != nil && *e.Response.Error != "" && !strings.Contains(msg, *e.Response.Error) {
+		return fmt.Sprintf("%s (server: %s)", msg, *e.Response.Error)
+	}
+	return msg
+}
+
+func (e *PaymentRequiredError) Unwrap() error {
+	return e.Err
+}
+
+// rejectionReason extracts a human readable reason from an error response body,
+// preferring the JSON error fields used by facilitators and resource servers
+func rejectionReason(body []byte) string {
+	var fields struct {
+		Error         string `json:"error"`
+		InvalidReason string `json:"invalidReason"`
+		Message       string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &fields); err == nil {
+		switch {
+		case fields.Error != "":
+			return fields.Error
+		case fields.InvalidReason != "":
+			return fields.InvalidReason
+		case fields.Message != "":
+			return fields.Message
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// InvalidReason classifies why a payment failed verification
+type InvalidReason string
+
+// Known verification failure reasons. Facilitators and resource servers
+// report them under several spellings; see invalidReasons.
+const (
+	ReasonUnknown                  InvalidReason = "unknown"
+	ReasonAuthorizationExpired     InvalidReason = "authorization_expired"
+	ReasonAuthorizationNotYetValid InvalidReason = "authorization_not_yet_valid"
+	ReasonInsufficientFunds        InvalidReason = "insufficient_funds"
+	ReasonNonceReused              InvalidReason = "nonce_reused"
+	ReasonInvalidSignature         InvalidReason = "invalid_signature"
+	ReasonAmountMismatch           InvalidReason = "amount_mismatch"
+	ReasonRecipientMismatch        InvalidReason = "recipient_mismatch"
+	ReasonInvalidNetwork           InvalidReason = "invalid_network"
+	ReasonUnsupportedScheme        InvalidReason = "unsupported_scheme"
+)
+
+// invalidReasons maps raw reason strings onto InvalidReason
+var invalidReasons = map[string]InvalidReason{
+	"authorization_expired": ReasonAuthorizationExpired,
+	"expired":               ReasonAuthorizationExpired,
+	"invalid_exact_evm_payload_authorization_valid_before": ReasonAuthorizationExpired,
+
+	"authorization_not_yet_valid":                         ReasonAuthorizationNotYetValid,
+	"invalid_exact_evm_payload_authorization_valid_after": ReasonAuthorizationNotYetValid,
+
+	"insufficient_funds":   ReasonInsufficientFunds,
+	"insufficient_balance": ReasonInsufficientFunds,
+
+	"nonce_reused":               ReasonNonceReused,
+	"nonce_already_used":         ReasonNonceReused,
+	"authorization_already_used": ReasonNonceReused,
+
+	"invalid_signature":                               ReasonInvalidSignature,
+	"invalid_exact_evm_payload_signature":             ReasonInvalidSignature,
+	"invalid_exact_svm_payload_transaction_signature": ReasonInvalidSignature,
+
+	"amount_mismatch": ReasonAmountMismatch,
+	"invalid_exact_evm_payload_authorization_value": ReasonAmountMismatch,
+
+	"recipient_mismatch":                           ReasonRecipientMismatch,
+	"invalid_exact_evm_payload_recipient_mismatch": ReasonRecipientMismatch,
+
+	"invalid_network": ReasonInvalidNetwork,
+
+	"invalid_scheme":     ReasonUnsupportedScheme,
+	"unsupported_scheme": ReasonUnsupportedScheme,
+}
+
+// ParseInvalidReason maps a raw reason string onto a known InvalidReason,
+// returning ReasonUnknown for reasons it does not recognise
+func ParseInvalidReason(raw string) InvalidReason {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+	if reason, exists := invalidReasons[key]; exists {
+		return reason
+	}
+	return ReasonUnknown
+}
+
+// VerificationError reports a payment that was checked and found invalid,
+// either by the facilitator answering isValid:false or by the resource server
+// rejecting the paid request. Use errors.As to switch on Reason; RawReason
+// keeps the original string.
+type VerificationError struct {
+	// Result is the verification result, reconstructed from the rejection
+	// body when the resource server rejected the payment
+	Result *VerificationResult
+	// Reason classifies RawReason, ReasonUnknown when it is not recognised
+	Reason InvalidReason
+	// RawReason is the reason string as reported
+	RawReason string
+	// Err is the underlying *PaymentError for resource server rejections
+	Err error
+}
+
+// newVerificationError classifies an invalid verification result
+func newVerificationError(result *VerificationResult, err error) *VerificationError {
+	var raw string
+	if result != nil && result.InvalidReason != nil {
+		raw = *result.InvalidReason
+	}
+	return &VerificationError{
+		Result:    result,
+		Reason:    ParseInvalidReason(raw),
+		RawReason: raw,
+		Err:       err,
+	}
+}
+
+func (e *VerificationError) Error() string {
+	if e.RawReason == "" {
+		return "payment verification failed"
+	}
+	return fmt.Sprintf("payment verification failed: %s", e.RawReason)
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// Is lets errors.Is match ErrInsufficientFunds for insufficient balance rejections
+func (e *VerificationError) Is(target error) bool {
+	return target == ErrInsufficientFunds && e.Reason == ReasonInsufficientFunds
+}
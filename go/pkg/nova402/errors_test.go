@@ -0,0 +1,113 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// rejectingFacilitator rejects every payment with reason
+type rejectingFacilitator struct{ reason string }
+
+func (f rejectingFacilitator) Verify(ctx context.Context, header PaymentHeader, requirements PaymentRequirements) (*VerificationResult, error) {
+	return &VerificationResult{IsValid: false, InvalidReason: &f.reason}, nil
+}
+
+func (f rejectingFacilitator) Settle(ctx context.Context, header PaymentHeader, requirements PaymentRequirements) (*SettlementResult, error) {
+	return nil, errors.New("settle called after a rejected verification")
+}
+
+func TestVerificationErrorReachesPayer(t *testing.T) {
+	// The resource server verifies through a facilitator that reports
+	// insufficient funds and passes the raw reason back in its 402
+	server := NewClient("base-sepolia", "", WithFacilitator(rejectingFacilitator{"insufficient_funds"}))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		header, err := ParsePaymentHeader(r.Header.Get("X-PAYMENT"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, err = server.Verify(*header, testRequirements())
+		var verr *VerificationError
+		if !errors.As(err, &verr) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(`{"x402Version":1,"error":"` + verr.RawReason + `","accepts":[]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	_, err := c.Get(srv.URL, nil)
+	var verr *VerificationError
+	if !errors.As(err, &verr) || verr.Reason != ReasonInsufficientFunds {
+		t.Fatalf("err = %v, want a VerificationError for insufficient funds", err)
+	}
+	var perr *PaymentError
+	if !errors.As(err, &perr) || perr.StatusCode != http.StatusPaymentRequired {
+		t.Fatalf("err = %v, want a PaymentError with status 402", err)
+	}
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("err = %v, want it to match ErrInsufficientFunds", err)
+	}
+}
+
+func TestParseInvalidReason(t *testing.T) {
+	for raw, want := range map[string]InvalidReason{
+		"insufficient_funds": ReasonInsufficientFunds,
+		"invalid_exact_evm_payload_authorization_valid_before": ReasonAuthorizationExpired,
+		"zzz": ReasonUnknown,
+	} {
+		if got := ParseInvalidReason(raw); got != want {
+			t.Fatalf("ParseInvalidReason(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestPaymentRequiredErrorKeepsBody(t *testing.T) {
+	body := `{"x402Version":1,"error":"top up at example.com","accepts":[{"scheme":"exact","network":"base-sepolia","maxAmountRequired":"1000","payTo":"0x209693Bc6afc0C5328bA36FaF03C514EF312287C","maxTimeoutSeconds":60}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithMaxPaymentAmount("base-sepolia", big.NewInt(10))).WithPrivateKey(testKey)
+	_, err := c.Get(srv.URL, nil)
+	var prerr *PaymentRequiredError
+	if !errors.As(err, &prerr) || !errors.Is(err, ErrAmountExceedsLimit) {
+		t.Fatalf("err = %v, want a PaymentRequiredError wrapping ErrAmountExceedsLimit", err)
+	}
+	if string(prerr.Body) != body {
+		t.Fatalf("Body = %q, want the raw 402 body", prerr.Body)
+	}
+	if prerr.Response == nil || prerr.Response.Error == nil || *prerr.Response.Error != "top up at example.com" || len(prerr.Response.Accepts) != 1 {
+		t.Fatalf("Response = %+v, want the decoded 402", prerr.Response)
+	}
+}
+
+func TestPaymentRequiredErrorAfterRejectedPayment(t *testing.T) {
+	// The server answers the paid retry with another 402
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(paid402))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	_, err := c.Get(srv.URL, nil)
+	var prerr *PaymentRequiredError
+	var verr *VerificationError
+	if !errors.As(err, &prerr) || !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want a PaymentRequiredError wrapping a VerificationError", err)
+	}
+}
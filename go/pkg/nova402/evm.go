@@ -0,0 +1,502 @@
+package nova402
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ERC-20 and EIP-3009 function selectors
+const (
+	balanceOfSelector                      = "70a08231"
+	transferWithAuthorizationSelector      = "e3ee160e"
+	transferWithAuthorizationBytesSelector = "cf092995"
+	receiveWithAuthorizationSelector       = "ef55bec6"
+	receiveWithAuthorizationBytesSelector  = "88b7ab63"
+)
+
+// buildAuthorization prepares and signs an EIP-3009 authorization for the
+// requirements, valid between the given unix timestamps
+func (c *Client) buildAuthorization(ctx context.Context, requirements PaymentRequirements, validAfter, validBefore int64) (*EIP3009Authorization, error) {
+	value, err := c.paymentValue(ctx, requirements)
+	if err != nil {
+		return nil, err
+	}
+	return c.signAuthorizationFor(ctx, requirements, value, validAfter, validBefore)
+}
+
+// signAuthorizationFor signs an authorization transferring value to the
+// requirements' payee under a fresh nonce
+func (c *Client) signAuthorizationFor(ctx context.Context, requirements PaymentRequirements, value string, validAfter, validBefore int64) (*EIP3009Authorization, error) {
+	signer, err := c.signer()
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := c.Address()
+	if err != nil {
+		return nil, err
+	}
+	if from, err = ToChecksumAddress(from); err != nil {
+		return nil, fmt.Errorf("payer address: %w", err)
+	}
+	to, err := ToChecksumAddress(requirements.PayTo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: payTo: %v", ErrInvalidRequirements, err)
+	}
+
+	nonce, err := c.newNonce(requirements.PayTo)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := &EIP3009Authorization{
+		From:        from,
+		To:          to,
+		Value:       value,
+		ValidAfter:  validAfter,
+		ValidBefore: validBefore,
+		Nonce:       nonce,
+	}
+	if err := signAuthorization(signer, requirements, auth, c.ContractWallet != ""); err != nil {
+		return nil, err
+	}
+	if c.VerifySignatures {
+		if err := c.checkAuthorizationSignature(ctx, requirements, auth); err != nil {
+			return nil, err
+		}
+	}
+	return auth, nil
+}
+
+// checkAuthorizationSignature fails with ErrInvalidSignature when auth is not
+// signed by its From address in the requirements' domain. A contract wallet
+// is asked through EIP-1271; an EOA's signature is recovered locally.
+func (c *Client) checkAuthorizationSignature(ctx context.Context, requirements PaymentRequirements, auth *EIP3009Authorization) error {
+	domain, err := authorizationDomain(requirements)
+	if err != nil {
+		return err
+	}
+	authType, err := authorizationType(requirements)
+	if err != nil {
+		return err
+	}
+
+	var valid bool
+	if c.ContractWallet != "" {
+		config, err := GetNetworkConfig(requirements.Network)
+		if err != nil {
+			return err
+		}
+		valid, err = c.verifyAuthorizationOnChain(ctx, config, domain, *auth, authType)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+	} else if valid, err = verifyAuthorization(domain, *auth, authType); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if !valid {
+		return fmt.Errorf("%w: signature is not valid for %s", ErrInvalidSignature, auth.From)
+	}
+	return nil
+}
+
+// signAuthorization signs auth as EIP-712 typed data of the requirements'
+// authorization variant and asset, and fills in its v, r and s, or its
+// Signature when signing for a contract wallet
+func signAuthorization(signer Signer, requirements PaymentRequirements, auth *EIP3009Authorization, contractWallet bool) error {
+	domain, err := authorizationDomain(requirements)
+	if err != nil {
+		return err
+	}
+
+	authType, err := authorizationType(requirements)
+	if err != nil {
+		return err
+	}
+	if authType == AuthTypePermit {
+		return fmt.Errorf("%w: permit requirements take an EIP-2612 permit, not an EIP-3009 authorization", ErrInvalidRequirements)
+	}
+
+	sig, err := signer.SignTypedData(domain, authorizationTypedData(auth, authType))
+	if err != nil {
+		return fmt.Errorf("failed to sign authorization: %w", err)
+	}
+	if contractWallet {
+		if len(sig) == 0 {
+			return fmt.Errorf("signer returned an empty signature")
+		}
+		auth.Signature = hexutil.Encode(sig)
+		return nil
+	}
+	auth.V, auth.R, auth.S, err = splitSignature(sig)
+	return err
+}
+
+// splitSignature splits a 65-byte r || s || v signature into its parts as
+// carried in payment payloads, with v normalized to 27 or 28
+func splitSignature(sig []byte) (v int, r, s string, err error) {
+	if len(sig) != 65 {
+		return 0, "", "", fmt.Errorf("signer returned %d-byte signature, expected 65", len(sig))
+	}
+	v = int(sig[64])
+	if v < 27 {
+		v += 27
+	}
+	v, r, s = NormalizeSignature(v, hexutil.Encode(sig[:32]), hexutil.Encode(sig[32:64]))
+	return v, r, s, nil
+}
+
+// secp256k1HalfN is half the secp256k1 curve order, the largest canonical s
+var secp256k1HalfN = new(big.Int).Rsh(crypto.S256().Params().N, 1)
+
+// NormalizeSignature returns the low-s form of a signature. A high s is
+// replaced by N - s and v is flipped between 27 and 28, which recovers the
+// same signer; verifiers following EIP-2 reject the high-s form. Values that
+// are not a well-formed 32-byte s and 27/28 v are returned unchanged.
+func NormalizeSignature(v int, r, s string) (int, string, string) {
+	raw, err := hexutil.Decode(s)
+	if err != nil || len(raw) != 32 || (v != 27 && v != 28) {
+		return v, r, s
+	}
+
+	value := new(big.Int).SetBytes(raw)
+	if value.Cmp(secp256k1HalfN) <= 0 {
+		return v, r, s
+	}
+	value.Sub(crypto.S256().Params().N, value)
+	return 55 - v, r, hexutil.Encode(common.LeftPadBytes(value.Bytes(), 32))
+}
+
+// paymentValue returns the base-unit amount to sign. The upto scheme lets the
+// client commit an amount set by WithAmount on ctx, or else PayAmount, instead
+// of the maximum; every other scheme pays MaxAmountRequired exactly.
+func (c *Client) paymentValue(ctx context.Context, requirements PaymentRequirements) (string, error) {
+	payAmount, perRequest := amountFromContext(ctx)
+	if !perRequest {
+		payAmount = c.PayAmount
+	}
+	if PaymentScheme(requirements.Scheme) != SchemeUpto {
+		if perRequest && payAmount != requirements.MaxAmountRequired {
+			return "", fmt.Errorf("%w: %s scheme requires exactly %s, cannot pay %s", ErrInvalidAmount, requirements.Scheme, requirements.MaxAmountRequired, payAmount)
+		}
+		return requirements.MaxAmountRequired, nil
+	}
+	if payAmount == "" {
+		return requirements.MaxAmountRequired, nil
+	}
+
+	value, ok := new(big.Int).SetString(payAmount, 10)
+	if !ok || value.Sign() <= 0 {
+		return "", fmt.Errorf("%w: pay amount %q must be a positive integer", ErrInvalidAmount, payAmount)
+	}
+	max, ok := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid maxAmountRequired %q", requirements.MaxAmountRequired)
+	}
+	if value.Cmp(max) > 0 {
+		return "", fmt.Errorf("%w: pay amount %s exceeds maxAmountRequired %s", ErrInvalidAmount, value, max)
+	}
+	return value.String(), nil
+}
+
+// checkPaymentLimit fails with ErrAmountExceedsLimit when amount is above the
+// network's MaxPaymentAmount
+func (c *Client) checkPaymentLimit(network, amount string) error {
+	network = canonicalNetwork(network)
+	limit := networkLimit(c.MaxPaymentAmount, network)
+	if limit == nil || limit.Sign() == 0 {
+		return nil
+	}
+	if limit.Sign() < 0 {
+		return fmt.Errorf("invalid payment limit %s for %s: must not be negative", limit, network)
+	}
+
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %q", amount)
+	}
+	if value.Cmp(limit) > 0 {
+		return fmt.Errorf("%w: payment of %s exceeds the %s limit of %s", ErrAmountExceedsLimit, value, network, limit)
+	}
+	return nil
+}
+
+// BalanceOf returns the USDC balance of address on an EVM network, in base units
+func (c *Client) BalanceOf(address, network string) (*big.Int, error) {
+	return c.BalanceOfWithContext(context.Background(), address, network)
+}
+
+// BalanceOfWithContext is BalanceOf with a caller-supplied context
+func (c *Client) BalanceOfWithContext(ctx context.Context, address, network string) (*big.Int, error) {
+	token, err := GetUSDCAddress(network)
+	if err != nil {
+		return nil, err
+	}
+	return c.balanceOf(ctx, token, address, network)
+}
+
+// balanceOf returns the balance of address in the ERC-20 token at token
+func (c *Client) balanceOf(ctx context.Context, token, address, network string) (*big.Int, error) {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return nil, err
+	}
+	if config.Type != NetworkTypeEVM {
+		return nil, fmt.Errorf("%w: balance lookup for %s networks", ErrNotImplemented, config.Type)
+	}
+
+	if !isValidAddress(address, NetworkTypeEVM) {
+		return nil, fmt.Errorf("invalid EVM address: %s", address)
+	}
+
+	data := "0x" + balanceOfSelector + strings.Repeat("0", 24) + strings.ToLower(address[2:])
+	call := map[string]string{"to": token, "data": data}
+
+	var result string
+	if err := c.callRPC(ctx, config, "eth_call", []interface{}{call, "latest"}, &result); err != nil {
+		return nil, fmt.Errorf("balanceOf call failed: %w", err)
+	}
+
+	raw, err := hexutil.Decode(result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid balanceOf result %q: %w", result, err)
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// checkBalance fails with ErrInsufficientFunds when the payer cannot cover amount
+func (c *Client) checkBalance(ctx context.Context, requirements PaymentRequirements, amount string) error {
+	address, err := c.Address()
+	if err != nil {
+		return err
+	}
+
+	token, err := requirementsAsset(requirements)
+	if err != nil {
+		return err
+	}
+	balance, err := c.balanceOf(ctx, token, address, requirements.Network)
+	if err != nil {
+		return err
+	}
+
+	required, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %q", amount)
+	}
+	if balance.Cmp(required) < 0 {
+		return fmt.Errorf("%w: %s has %s, payment requires %s", ErrInsufficientFunds, address, balance, required)
+	}
+	return nil
+}
+
+// Address returns the checksummed EVM address the client pays from: its
+// ContractWallet when set, otherwise the signer's address
+func (c *Client) Address() (string, error) {
+	if c.ContractWallet != "" {
+		return ToChecksumAddress(c.ContractWallet)
+	}
+	signer, err := c.signer()
+	if err != nil {
+		return "", err
+	}
+	return signer.Address()
+}
+
+// AddressFromPrivateKey derives the checksummed EVM address for a secp256k1
+// private key given as hex, with or without a 0x prefix
+func AddressFromPrivateKey(hexKey string) (string, error) {
+	key, err := parsePrivateKey(hexKey)
+	if err != nil {
+		return "", err
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex(), nil
+}
+
+// parsePrivateKey decodes a hex secp256k1 private key
+func parsePrivateKey(hexKey string) (*ecdsa.PrivateKey, error) {
+	hexKey = strings.TrimSpace(hexKey)
+	if len(hexKey) >= 2 && (hexKey[:2] == "0x" || hexKey[:2] == "0X") {
+		hexKey = hexKey[2:]
+	}
+	key, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+	return key, nil
+}
+
+// EstimateGas estimates the gas needed to submit auth to the network's USDC
+// contract via transferWithAuthorization, for clients that settle themselves.
+// The call is estimated as sent from PrivateKey's address when one is set.
+func (c *Client) EstimateGas(network string, auth EIP3009Authorization) (uint64, error) {
+	return c.EstimateGasWithContext(context.Background(), network, auth)
+}
+
+// EstimateGasWithContext is EstimateGas with a caller-supplied context
+func (c *Client) EstimateGasWithContext(ctx context.Context, network string, auth EIP3009Authorization) (uint64, error) {
+	token, err := GetUSDCAddress(network)
+	if err != nil {
+		return 0, err
+	}
+	return c.estimateGas(ctx, network, token, AuthTypeTransfer, auth)
+}
+
+// EstimateGasFor is EstimateGas for an authorization paying requirements: it
+// is estimated against the requirements' asset, with the EIP-3009 call their
+// authType selects
+func (c *Client) EstimateGasFor(requirements PaymentRequirements, auth EIP3009Authorization) (uint64, error) {
+	return c.EstimateGasForWithContext(context.Background(), requirements, auth)
+}
+
+// EstimateGasForWithContext is EstimateGasFor with a caller-supplied context
+func (c *Client) EstimateGasForWithContext(ctx context.Context, requirements PaymentRequirements, auth EIP3009Authorization) (uint64, error) {
+	asset, authType, err := directSettlementTarget(requirements)
+	if err != nil {
+		return 0, err
+	}
+	return c.estimateGas(ctx, requirements.Network, asset, authType, auth)
+}
+
+// estimateGas estimates submitting auth to the token at asset as authType
+func (c *Client) estimateGas(ctx context.Context, network, asset, authType string, auth EIP3009Authorization) (uint64, error) {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return 0, err
+	}
+	if config.Type != NetworkTypeEVM {
+		return 0, fmt.Errorf("%w: gas estimation requires an EVM network, got %s", ErrUnsupportedNetwork, network)
+	}
+
+	data, err := encodeAuthorizationCall(auth, authType)
+	if err != nil {
+		return 0, err
+	}
+
+	call := map[string]string{"to": asset, "data": hexutil.Encode(data)}
+	if from, err := c.directSender(); err == nil {
+		call["from"] = from
+	}
+
+	var result string
+	if err := c.callRPC(ctx, config, "eth_estimateGas", []interface{}{call}, &result); err != nil {
+		return 0, fmt.Errorf("gas estimation failed: %w", err)
+	}
+	gas, err := hexutil.DecodeUint64(result)
+	if err != nil {
+		return 0, fmt.Errorf("invalid gas estimate %q: %w", result, err)
+	}
+	return gas, nil
+}
+
+// directSender returns the address direct settlement sends transactions
+// from: PrivateKey's, which differs from Address for a contract wallet
+func (c *Client) directSender() (string, error) {
+	if c.PrivateKey == "" {
+		return "", fmt.Errorf("%w: direct settlement signs transactions with PrivateKey", ErrNoPrivateKey)
+	}
+	key, err := parsePrivateKey(c.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex(), nil
+}
+
+// directSettlementTarget returns the token contract and EIP-3009 call an
+// authorization paying requirements is submitted with. Permit payments
+// cannot be settled directly.
+func directSettlementTarget(requirements PaymentRequirements) (string, string, error) {
+	asset, err := requirementsAsset(requirements)
+	if err != nil {
+		return "", "", err
+	}
+	if !common.IsHexAddress(asset) {
+		return "", "", fmt.Errorf("invalid token address %q", asset)
+	}
+	authType, err := authorizationType(requirements)
+	if err != nil {
+		return "", "", err
+	}
+	if authType == AuthTypePermit {
+		return "", "", fmt.Errorf("%w: direct settlement of permit payments", ErrNotImplemented)
+	}
+	return asset, authType, nil
+}
+
+// encodeAuthorizationCall ABI-encodes a signed authorization as a
+// transferWithAuthorization or receiveWithAuthorization(from, to, value,
+// validAfter, validBefore, nonce, v, r, s) call, as authType selects, or as
+// the overload taking signature bytes when auth carries a Signature
+func encodeAuthorizationCall(auth EIP3009Authorization, authType string) ([]byte, error) {
+	selectorHex, bytesSelectorHex := transferWithAuthorizationSelector, transferWithAuthorizationBytesSelector
+	switch authType {
+	case AuthTypeTransfer:
+	case AuthTypeReceive:
+		selectorHex, bytesSelectorHex = receiveWithAuthorizationSelector, receiveWithAuthorizationBytesSelector
+	default:
+		return nil, fmt.Errorf("%w: cannot submit %q authorizations", ErrInvalidRequirements, authType)
+	}
+	if !common.IsHexAddress(auth.From) || !common.IsHexAddress(auth.To) {
+		return nil, fmt.Errorf("invalid authorization addresses %q and %q", auth.From, auth.To)
+	}
+	value, ok := new(big.Int).SetString(auth.Value, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid authorization value %q", auth.Value)
+	}
+
+	type field struct{ name, value string }
+	fields := []field{{"nonce", auth.Nonce}}
+	if auth.Signature == "" {
+		fields = append(fields, field{"r", auth.R}, field{"s", auth.S})
+	}
+	var words [][]byte
+	for _, field := range fields {
+		word, err := hexutil.Decode(field.value)
+		if err != nil || len(word) != 32 {
+			return nil, fmt.Errorf("invalid authorization %s %q: must be 32 bytes of hex", field.name, field.value)
+		}
+		words = append(words, word)
+	}
+
+	if auth.Signature != "" {
+		selectorHex = bytesSelectorHex
+	}
+	selector, _ := hexutil.Decode("0x" + selectorHex)
+	data := append([]byte{}, selector...)
+	data = append(data, common.LeftPadBytes(common.HexToAddress(auth.From).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(common.HexToAddress(auth.To).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(value.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(auth.ValidAfter).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(auth.ValidBefore).Bytes(), 32)...)
+	data = append(data, words[0]...)
+
+	// A contract wallet's signature goes to the bytes overload, as a dynamic
+	// argument after the seven head words
+	if auth.Signature != "" {
+		sig, err := hexutil.Decode(auth.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("invalid authorization signature %q: must be hex", auth.Signature)
+		}
+		data = append(data, common.LeftPadBytes(big.NewInt(7*32).Bytes(), 32)...)
+		return append(data, encodeDynamicBytes(sig)...), nil
+	}
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(auth.V)).Bytes(), 32)...)
+	data = append(data, words[1]...)
+	data = append(data, words[2]...)
+	return data, nil
+}
+
+// encodeDynamicBytes ABI-encodes b as the tail of a bytes argument: its
+// length, then its content right-padded to a multiple of 32 bytes
+func encodeDynamicBytes(b []byte) []byte {
+	padded := (len(b) + 31) / 32 * 32
+	out := common.LeftPadBytes(big.NewInt(int64(len(b))).Bytes(), 32)
+	return append(out, common.RightPadBytes(b, padded)...)
+}
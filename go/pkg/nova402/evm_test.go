@@ -0,0 +1,141 @@
+package nova402
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestOverCapPaymentRefusedWithoutSigning(t *testing.T) {
+	var paid atomic.Int32
+	srv := paidServer(func(http.ResponseWriter) { paid.Add(1) })
+	defer srv.Close()
+	local, err := NewLocalSigner(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer := &countingSigner{Signer: local}
+	c := NewClient("base-sepolia", "", WithSigner(signer), WithMaxPaymentAmount("base-sepolia", big.NewInt(999)))
+	if _, err := c.Get(srv.URL, nil); !errors.Is(err, ErrAmountExceedsLimit) {
+		t.Fatalf("err = %v, want ErrAmountExceedsLimit", err)
+	}
+	if signer.signed != 0 || paid.Load() != 0 {
+		t.Fatalf("over-cap payment signed %d times and sent %d times, want neither", signer.signed, paid.Load())
+	}
+
+	c = NewClient("base-sepolia", "", WithSigner(signer), WithMaxPaymentAmount("base-sepolia", big.NewInt(1000)))
+	resp, err := c.Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("payment at the cap: %v", err)
+	}
+	resp.Body.Close()
+	if signer.signed != 1 || paid.Load() != 1 {
+		t.Fatalf("payment at the cap signed %d times and sent %d times, want once", signer.signed, paid.Load())
+	}
+}
+
+func TestNegativePaymentCapRejected(t *testing.T) {
+	if _, err := NewClientWithOptionsE(WithMaxPaymentAmount("base-sepolia", big.NewInt(-1))); err == nil {
+		t.Fatal("NewClientWithOptionsE accepted a negative payment cap")
+	}
+	c := NewClient("base-sepolia", "", WithMaxPaymentAmount("base-sepolia", big.NewInt(-1)))
+	if err := c.checkPaymentLimit("base-sepolia", "1"); err == nil {
+		t.Fatal("checkPaymentLimit accepted a negative cap")
+	}
+}
+
+const transferWithAuthorizationABI = `[{"name":"transferWithAuthorization","type":"function","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"validAfter","type":"uint256"},{"name":"validBefore","type":"uint256"},{"name":"nonce","type":"bytes32"},{"name":"v","type":"uint8"},{"name":"r","type":"bytes32"},{"name":"s","type":"bytes32"}]}]`
+
+func TestEncodeAuthorizationCallMatchesABI(t *testing.T) {
+	auth := signedAuthorization(t)
+	got, err := encodeAuthorizationCall(*auth, AuthTypeTransfer)
+	if err != nil {
+		t.Fatalf("encodeAuthorizationCall: %v", err)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(transferWithAuthorizationABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nonce, r, s [32]byte
+	copy(nonce[:], hexutil.MustDecode(auth.Nonce))
+	copy(r[:], hexutil.MustDecode(auth.R))
+	copy(s[:], hexutil.MustDecode(auth.S))
+	want, err := parsed.Pack("transferWithAuthorization",
+		common.HexToAddress(auth.From), common.HexToAddress(auth.To), big.NewInt(1000),
+		big.NewInt(auth.ValidAfter), big.NewInt(auth.ValidBefore), nonce, uint8(auth.V), r, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("call data = %x, want %x", got, want)
+	}
+}
+
+func TestEstimateGas(t *testing.T) {
+	var method string
+	var call map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string
+			Params []map[string]string
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		method = req.Method
+		if len(req.Params) > 0 {
+			call = req.Params[0]
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1d4c0"}`))
+	}))
+	defer srv.Close()
+	registerTestNetwork(t, "gas-test", NetworkConfig{ChainID: 1, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+	usdc := "0xaf88d065e77c8cC2239327C5EDb3A432268e5831"
+	if err := RegisterUSDC("gas-test", usdc, false); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := signedAuthorization(t)
+	c := NewClient("gas-test", "").WithPrivateKey(testKey)
+	gas, err := c.EstimateGas("gas-test", *auth)
+	if err != nil {
+		t.Fatalf("EstimateGas: %v", err)
+	}
+	if gas != 120000 {
+		t.Fatalf("gas = %d, want 120000", gas)
+	}
+	sender, _ := AddressFromPrivateKey(testKey)
+	if method != "eth_estimateGas" || call["to"] != usdc || call["from"] != sender {
+		t.Fatalf("estimated %s %v, want eth_estimateGas to %s from %s", method, call, usdc, sender)
+	}
+
+	if _, err := c.EstimateGas("solana-devnet", *auth); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("Solana network: err = %v, want ErrUnsupportedNetwork", err)
+	}
+}
+
+func TestNormalizeSignatureFlipsHighS(t *testing.T) {
+	auth := signedAuthorization(t)
+
+	// Build the equivalent high-s signature: s' = n - s with the parity of v flipped
+	low, _ := hexutil.Decode(auth.S)
+	high := new(big.Int).Sub(crypto.S256().Params().N, new(big.Int).SetBytes(low))
+	v, r, s := NormalizeSignature(55-auth.V, auth.R, hexutil.Encode(common.LeftPadBytes(high.Bytes(), 32)))
+	if v != auth.V || r != auth.R || s != auth.S {
+		t.Fatalf("NormalizeSignature = %d, %s, %s; want %d, %s, %s", v, r, s, auth.V, auth.R, auth.S)
+	}
+	if ok, err := VerifyAuthorizationSignature(*auth, "base-sepolia"); !ok {
+		t.Fatalf("normalized signature does not verify: %v", err)
+	}
+}
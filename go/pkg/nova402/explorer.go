@@ -0,0 +1,41 @@
+package nova402
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ExplorerTxURL returns a link to a transaction on the network's block
+// explorer. EVM explorers and the Solana explorer both serve transactions
+// under /tx/; query parameters on the configured explorer, such as Solana's
+// ?cluster=devnet, are kept, and added for Solana clusters other than
+// mainnet when missing.
+func ExplorerTxURL(network, txHash string) (string, error) {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return "", err
+	}
+	if config.Explorer == "" {
+		return "", fmt.Errorf("network %s has no explorer configured", network)
+	}
+	if txHash == "" {
+		return "", fmt.Errorf("transaction hash is required")
+	}
+
+	explorer, err := url.Parse(config.Explorer)
+	if err != nil {
+		return "", fmt.Errorf("invalid explorer URL for %s: %w", network, err)
+	}
+	explorer.Path = strings.TrimSuffix(explorer.Path, "/") + "/tx/" + url.PathEscape(txHash)
+
+	if config.Type == NetworkTypeSolana {
+		cluster, _ := config.ChainID.(string)
+		query := explorer.Query()
+		if cluster != "" && cluster != "mainnet" && query.Get("cluster") == "" {
+			query.Set("cluster", cluster)
+			explorer.RawQuery = query.Encode()
+		}
+	}
+	return explorer.String(), nil
+}
@@ -0,0 +1,23 @@
+package nova402
+
+import "testing"
+
+func TestExplorerTxURL(t *testing.T) {
+	for network, tc := range map[string]struct{ hash, want string }{
+		"base-mainnet":   {"0xabc", "https://basescan.org/tx/0xabc"},
+		"solana-devnet":  {"5sig", "https://explorer.solana.com/tx/5sig?cluster=devnet"},
+		"solana-mainnet": {"5sig", "https://explorer.solana.com/tx/5sig"},
+	} {
+		got, err := ExplorerTxURL(network, tc.hash)
+		if err != nil || got != tc.want {
+			t.Fatalf("ExplorerTxURL(%q) = %q, %v; want %q", network, got, err, tc.want)
+		}
+	}
+}
+
+func TestExplorerTxURLWithoutExplorer(t *testing.T) {
+	registerTestNetwork(t, "noexp", NetworkConfig{ChainID: 5, Type: NetworkTypeEVM})
+	if _, err := ExplorerTxURL("noexp", "0x1"); err == nil {
+		t.Fatal("network without an explorer returned a URL")
+	}
+}
@@ -0,0 +1,348 @@
+package nova402
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize caps how much of a failed facilitator response is kept
+const maxErrorBodySize = 4096
+
+// facilitatorRequest is the body sent to the facilitator verify and settle endpoints
+type facilitatorRequest struct {
+	X402Version         int                 `json:"x402Version"`
+	PaymentPayload      PaymentHeader       `json:"paymentPayload"`
+	PaymentRequirements PaymentRequirements `json:"paymentRequirements"`
+}
+
+// SettlementError is returned when the facilitator answers successfully but reports success:false
+type SettlementError struct {
+	Result *SettlementResult
+}
+
+func (e *SettlementError) Error() string {
+	if e.Result != nil && e.Result.Error != nil {
+		return fmt.Sprintf("settlement failed: %s", *e.Result.Error)
+	}
+	return "settlement failed"
+}
+
+// Facilitator verifies and settles payments on behalf of a client. The
+// default implementation is HTTPFacilitator; tests can substitute a fake.
+type Facilitator interface {
+	Verify(ctx context.Context, header PaymentHeader, requirements PaymentRequirements) (*VerificationResult, error)
+	Settle(ctx context.Context, header PaymentHeader, requirements PaymentRequirements) (*SettlementResult, error)
+}
+
+// HTTPFacilitator talks to a facilitator's /verify and /settle HTTP endpoints
+type HTTPFacilitator struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewHTTPFacilitator creates a facilitator client for the given base URL.
+// A nil httpClient falls back to http.DefaultClient.
+func NewHTTPFacilitator(url string, httpClient *http.Client) *HTTPFacilitator {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &HTTPFacilitator{
+		URL:        url,
+		HTTPClient: httpClient,
+	}
+}
+
+// Verify posts the payment to the facilitator's /verify endpoint
+func (f *HTTPFacilitator) Verify(ctx context.Context, header PaymentHeader, requirements PaymentRequirements) (*VerificationResult, error) {
+	var result VerificationResult
+	if err := f.post(ctx, "/verify", header, requirements, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Settle posts the payment to the facilitator's /settle endpoint. The
+// response may be a single result, an array of attempts or a stream of
+// either; see SettlementResult.SettlementAttempts.
+func (f *HTTPFacilitator) Settle(ctx context.Context, header PaymentHeader, requirements PaymentRequirements) (*SettlementResult, error) {
+	var attempts settlementAttempts
+	if err := f.post(ctx, "/settle", header, requirements, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts.final(), nil
+}
+
+// streamDecoder is implemented by facilitator responses that may span more
+// than one JSON value
+type streamDecoder interface {
+	decodeStream(decoder *json.Decoder) error
+}
+
+// settlementAttempts collects the results of a settle response, which some
+// facilitators send as an array of attempts or a stream of status updates
+// rather than a single object
+type settlementAttempts []SettlementResult
+
+func (a *settlementAttempts) decodeStream(decoder *json.Decoder) error {
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if len(raw) > 0 && raw[0] == '[' {
+			var batch []SettlementResult
+			if err := json.Unmarshal(raw, &batch); err != nil {
+				return err
+			}
+			*a = append(*a, batch...)
+			continue
+		}
+		var attempt SettlementResult
+		if err := json.Unmarshal(raw, &attempt); err != nil {
+			return err
+		}
+		*a = append(*a, attempt)
+	}
+	if len(*a) == 0 {
+		return fmt.Errorf("no settlement result in response")
+	}
+	return nil
+}
+
+// final returns the last successful attempt, or the last attempt if none
+// succeeded, carrying the full history when there was more than one
+func (a settlementAttempts) final() *SettlementResult {
+	result := a[len(a)-1]
+	for i := len(a) - 1; i >= 0; i-- {
+		if a[i].Success {
+			result = a[i]
+			break
+		}
+	}
+	if len(a) > 1 {
+		result.SettlementAttempts = append([]SettlementResult(nil), a...)
+	}
+	return &result
+}
+
+func (f *HTTPFacilitator) post(ctx context.Context, path string, header PaymentHeader, requirements PaymentRequirements, out interface{}) error {
+	body, err := CanonicalJSON(facilitatorRequest{
+		X402Version:         header.X402Version,
+		PaymentPayload:      header,
+		PaymentRequirements: requirements,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal facilitator request: %w", err)
+	}
+
+	endpoint := strings.TrimRight(f.URL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key := idempotencyKeyFromContext(ctx); key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return f.do(req, path, out)
+}
+
+// do sends a facilitator request and decodes a successful JSON response into out
+func (f *HTTPFacilitator) do(req *http.Request, path string, out interface{}) error {
+	resp, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("facilitator request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return &PaymentError{
+			StatusCode: resp.StatusCode,
+			Reason:     rejectionReason(errBody),
+			Endpoint:   path,
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	decoder := json.NewDecoder(resp.Body)
+	if stream, ok := out.(streamDecoder); ok {
+		err = stream.decodeStream(decoder)
+	} else {
+		err = decoder.Decode(out)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse facilitator response: %w", err)
+	}
+	return nil
+}
+
+// Verify asks the facilitator whether a payment satisfies the given
+// requirements. When the facilitator reports isValid:false the result is
+// returned alongside a *VerificationError classifying the reason.
+func (c *Client) Verify(header PaymentHeader, requirements PaymentRequirements) (*VerificationResult, error) {
+	return c.VerifyWithContext(context.Background(), header, requirements)
+}
+
+// VerifyWithContext is Verify with a caller-supplied context
+func (c *Client) VerifyWithContext(ctx context.Context, header PaymentHeader, requirements PaymentRequirements) (*VerificationResult, error) {
+	c.logger().DebugContext(ctx, "x402: verifying payment", slog.Any("payment", header))
+	var result *VerificationResult
+	err := c.withRetry(ctx, func() error {
+		if err := c.waitRateLimit(ctx); err != nil {
+			return err
+		}
+		var err error
+		start := time.Now()
+		result, err = c.facilitator().Verify(ctx, header, requirements)
+		c.observer().OnVerify(time.Since(start), err)
+		return err
+	})
+	if err != nil {
+		c.logger().DebugContext(ctx, "x402: verify failed", slog.String("error", err.Error()))
+		return nil, err
+	}
+	if !result.IsValid {
+		return result, newVerificationError(result, nil)
+	}
+	return result, nil
+}
+
+// SettleOptions overrides where one settlement is sent, for deployments where
+// verification and settlement are operated by different parties. Empty fields
+// keep the client's configuration.
+type SettleOptions struct {
+	// FacilitatorURL settles through the facilitator at this base URL instead
+	// of the client's Facilitator or FacilitatorURL. It applies in
+	// SettlementModeFacilitator only.
+	FacilitatorURL string
+	// RPCUrl is the only JSON-RPC endpoint used by direct settlement, in place
+	// of the network's RPCUrl and RPCUrls. It applies in SettlementModeDirect
+	// only.
+	RPCUrl string
+}
+
+// validate checks that the overrides are absolute http(s) URLs
+func (o SettleOptions) validate() error {
+	for _, field := range []struct{ name, value string }{
+		{"facilitator URL", o.FacilitatorURL},
+		{"RPC URL", o.RPCUrl},
+	} {
+		if field.value == "" {
+			continue
+		}
+		parsed, err := url.Parse(field.value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid settlement %s %q: must be an http(s) URL", field.name, field.value)
+		}
+	}
+	return nil
+}
+
+// Settle asks the facilitator to settle a verified payment on-chain. When the
+// facilitator reports success:false the result is returned alongside a
+// *SettlementError so the facilitator's error message is not lost.
+//
+// With SettlementModeDirect the authorization is broadcast by the client
+// instead; see SettleDirect.
+//
+// Every attempt carries the same X-Idempotency-Key header, taken from
+// WithIdempotencyKey or else DefaultIdempotencyKey, so a retried settlement
+// cannot charge twice on facilitators that honor it.
+func (c *Client) Settle(header PaymentHeader, requirements PaymentRequirements) (*SettlementResult, error) {
+	return c.SettleWithContext(context.Background(), header, requirements)
+}
+
+// SettleWithContext is Settle with a caller-supplied context
+func (c *Client) SettleWithContext(ctx context.Context, header PaymentHeader, requirements PaymentRequirements) (*SettlementResult, error) {
+	return c.SettleWithOptions(ctx, header, requirements, SettleOptions{})
+}
+
+// SettleWithOptions is SettleWithContext sending the settlement to the
+// facilitator or RPC endpoint in opts rather than the one used for Verify
+func (c *Client) SettleWithOptions(ctx context.Context, header PaymentHeader, requirements PaymentRequirements, opts SettleOptions) (*SettlementResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if c.DryRun {
+		return dryRunSettlement(header, requirements), nil
+	}
+	if c.SettlementMode == SettlementModeDirect {
+		if header.Payload.Authorization == nil {
+			return nil, fmt.Errorf("%w: direct settlement requires an EIP-3009 authorization", ErrInvalidPaymentHeader)
+		}
+		asset, authType, err := directSettlementTarget(requirements)
+		if err != nil {
+			return nil, err
+		}
+		if opts.RPCUrl != "" {
+			ctx = withRPCOverride(ctx, opts.RPCUrl)
+		}
+		start := time.Now()
+		result, err := c.settleDirect(ctx, requirements.Network, asset, authType, *header.Payload.Authorization)
+		c.observer().OnSettle(time.Since(start), err)
+		if err == nil && result.Success {
+			c.recordSpend(ctx, requirements.Network, header.Payload.Authorization.Value)
+		}
+		return result, err
+	}
+
+	if idempotencyKeyFromContext(ctx) == "" {
+		if key := DefaultIdempotencyKey(header, requirements); key != "" {
+			ctx = WithIdempotencyKey(ctx, key)
+		}
+	}
+
+	facilitator := c.facilitator()
+	if opts.FacilitatorURL != "" {
+		facilitator = NewHTTPFacilitator(opts.FacilitatorURL, c.httpClient())
+	}
+
+	c.logger().DebugContext(ctx, "x402: settling payment", slog.Any("payment", header))
+	var result *SettlementResult
+	err := c.withRetry(ctx, func() error {
+		if err := c.waitRateLimit(ctx); err != nil {
+			return err
+		}
+		var err error
+		start := time.Now()
+		result, err = facilitator.Settle(ctx, header, requirements)
+		observed := err
+		if err == nil && !result.Success {
+			observed = &SettlementError{Result: result}
+		}
+		c.observer().OnSettle(time.Since(start), observed)
+		return err
+	})
+	if err != nil {
+		c.logger().DebugContext(ctx, "x402: settle failed", slog.String("error", err.Error()))
+		return nil, err
+	}
+	if !result.Success {
+		return result, &SettlementError{Result: result}
+	}
+	c.recordSpend(ctx, requirements.Network, c.paidAmount(ctx, &header, &requirements))
+	return result, nil
+}
+
+// facilitator returns the configured Facilitator, defaulting to one built
+// from FacilitatorURL and HTTPClient
+func (c *Client) facilitator() Facilitator {
+	if c.Facilitator != nil {
+		return c.Facilitator
+	}
+	return NewHTTPFacilitator(c.FacilitatorURL, c.httpClient())
+}
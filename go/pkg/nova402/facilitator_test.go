@@ -0,0 +1,56 @@
+package nova402
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// settleWith settles through a facilitator that answers with body
+func settleWith(t *testing.T, body string) (*SettlementResult, error) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	return NewHTTPFacilitator(srv.URL, nil).Settle(context.Background(), PaymentHeader{}, PaymentRequirements{})
+}
+
+func TestSettlementAttempts(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body     string
+		txHash   string
+		attempts int
+	}{
+		"single object": {`{"success":true,"txHash":"0x1"}`, "0x1", 0},
+		"array":         {`[{"success":false,"error":"nonce"},{"success":true,"txHash":"0x2"},{"success":false}]`, "0x2", 3},
+		"stream":        {"{\"success\":false,\"error\":\"a\"}\n{\"success\":true,\"txHash\":\"0x3\"}", "0x3", 2},
+	} {
+		result, err := settleWith(t, tc.body)
+		if err != nil {
+			t.Fatalf("%s: Settle: %v", name, err)
+		}
+		if !result.Success || result.TxHash == nil || *result.TxHash != tc.txHash {
+			t.Fatalf("%s: result = %+v, want the successful attempt %s", name, result, tc.txHash)
+		}
+		if len(result.SettlementAttempts) != tc.attempts {
+			t.Fatalf("%s: %d settlement attempts, want %d", name, len(result.SettlementAttempts), tc.attempts)
+		}
+	}
+}
+
+func TestSettlementAttemptsAllFailed(t *testing.T) {
+	result, err := settleWith(t, `[{"success":false,"error":"x"},{"success":false,"error":"y"}]`)
+	if err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	// The last attempt explains the outcome
+	if result.Success || result.Error == nil || *result.Error != "y" || len(result.SettlementAttempts) != 2 {
+		t.Fatalf("result = %+v, want failure y with 2 attempts", result)
+	}
+
+	if _, err := settleWith(t, `[]`); err == nil {
+		t.Fatal("empty attempt list accepted")
+	}
+}
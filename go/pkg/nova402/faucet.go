@@ -0,0 +1,83 @@
+package nova402
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// faucetRequest is the body posted to a faucet endpoint
+type faucetRequest struct {
+	Network string `json:"network"`
+	Address string `json:"address"`
+}
+
+// RequestFaucet asks the faucet at FaucetURL to send test USDC to address on
+// network and returns the faucet's transaction. It is for development only
+// and fails with ErrUnsupportedNetwork on anything but a test network, such
+// as base-sepolia or solana-devnet.
+//
+// The faucet is sent {"network": ..., "address": ...} as JSON and must answer
+// with a SettlementResult describing the transfer. A result with success
+// false is returned alongside an error carrying the faucet's message.
+func (c *Client) RequestFaucet(network, address string) (*SettlementResult, error) {
+	return c.RequestFaucetWithContext(context.Background(), network, address)
+}
+
+// RequestFaucetWithContext is RequestFaucet with a caller-supplied context
+func (c *Client) RequestFaucetWithContext(ctx context.Context, network, address string) (*SettlementResult, error) {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return nil, err
+	}
+	network = canonicalNetwork(network)
+	if !IsTestnet(network) {
+		return nil, fmt.Errorf("%w: faucets are only available on test networks, not %s", ErrUnsupportedNetwork, network)
+	}
+	if !isValidAddress(address, config.Type) {
+		return nil, fmt.Errorf("invalid %s address %q", config.Type, address)
+	}
+	if c.FaucetURL == "" {
+		return nil, fmt.Errorf("no faucet URL configured")
+	}
+
+	body, err := json.Marshal(faucetRequest{Network: network, Address: address})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal faucet request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.FaucetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("faucet request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return nil, fmt.Errorf("faucet returned status %d: %s", resp.StatusCode, rejectionReason(errBody))
+	}
+
+	var result SettlementResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse faucet response: %w", err)
+	}
+	if result.NetworkID == nil {
+		result.NetworkID = &network
+	}
+	if !result.Success {
+		reason := "no reason given"
+		if result.Error != nil {
+			reason = *result.Error
+		}
+		return &result, fmt.Errorf("faucet request failed: %s", reason)
+	}
+	return &result, nil
+}
@@ -0,0 +1,34 @@
+package nova402
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestFaucet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"txHash":"0xfeed"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClientWithOptions(WithFaucetURL(srv.URL))
+	result, err := c.RequestFaucet("base-sepolia", "0x209693Bc6afc0C5328bA36FaF03C514EF312287C")
+	if err != nil {
+		t.Fatalf("RequestFaucet: %v", err)
+	}
+	if result.TxHash == nil || *result.TxHash != "0xfeed" || result.NetworkID == nil || *result.NetworkID != "base-sepolia" {
+		t.Fatalf("result = %+v, want tx 0xfeed on base-sepolia", result)
+	}
+}
+
+func TestRequestFaucetRejectsInvalid(t *testing.T) {
+	c := NewClientWithOptions(WithFaucetURL("http://127.0.0.1:0"))
+	if _, err := c.RequestFaucet("base", "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("mainnet faucet: err = %v, want ErrUnsupportedNetwork", err)
+	}
+	if _, err := c.RequestFaucet("base-sepolia", "nope"); err == nil {
+		t.Fatal("invalid address accepted")
+	}
+}
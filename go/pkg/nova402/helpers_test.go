@@ -0,0 +1,89 @@
+package nova402
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testKey is a throwaway secp256k1 key used to sign test payments
+const testKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// paid402 is the 402 body served by paidServer: 1000 base units of USDC on
+// base-sepolia
+const paid402 = `{"x402Version":1,"accepts":[{"scheme":"exact","network":"base-sepolia","maxAmountRequired":"1000","payTo":"0x209693Bc6afc0C5328bA36FaF03C514EF312287C","maxTimeoutSeconds":60}]}`
+
+// paidServer answers requests without an X-PAYMENT header with paid402 and
+// paid requests with "ok", calling extra first when it is set
+func paidServer(extra func(w http.ResponseWriter)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		if extra != nil {
+			extra(w)
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+// registerTestNetwork registers cfg under name for the duration of the test
+func registerTestNetwork(t *testing.T, name string, cfg NetworkConfig) {
+	t.Helper()
+	cleanupNetwork(t, name)
+	if err := RegisterNetwork(name, cfg, false); err != nil {
+		t.Fatalf("RegisterNetwork(%q): %v", name, err)
+	}
+}
+
+// cleanupNetwork removes the network registered under name, and any tokens
+// registered for it in DefaultTokens, when the test ends
+func cleanupNetwork(t *testing.T, name string) {
+	t.Cleanup(func() {
+		networkRegistry.mu.Lock()
+		delete(networkRegistry.networks, name)
+		networkRegistry.mu.Unlock()
+
+		DefaultTokens.mu.Lock()
+		delete(DefaultTokens.tokens, name)
+		DefaultTokens.mu.Unlock()
+	})
+}
+
+// testRequirements returns exact requirements for 1000 base units of USDC on
+// base-sepolia
+func testRequirements() PaymentRequirements {
+	return PaymentRequirements{
+		X402Version:       1,
+		Scheme:            "exact",
+		Network:           "base-sepolia",
+		MaxAmountRequired: "1000",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+	}
+}
+
+// signedAuthorization returns an authorization signed with testKey paying
+// testRequirements
+func signedAuthorization(t *testing.T) *EIP3009Authorization {
+	t.Helper()
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	requirements := testRequirements()
+	validAfter, validBefore := requirements.ValidityWindow(time.Now())
+	auth, err := c.buildAuthorization(context.Background(), requirements, validAfter, validBefore)
+	if err != nil {
+		t.Fatalf("buildAuthorization: %v", err)
+	}
+	return auth
+}
+
+// rpcServer answers every JSON-RPC request with result, given as raw JSON
+func rpcServer(result string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+}
@@ -0,0 +1,48 @@
+package nova402
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// IdempotencyKeyHeader is the request header carrying the settlement
+// idempotency key. Facilitators should settle a given key at most once and
+// replay the original result for repeated requests.
+const IdempotencyKeyHeader = "X-Idempotency-Key"
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context that makes Settle send key as the
+// X-Idempotency-Key header instead of the default derived from the payment
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// idempotencyKeyFromContext returns the key set by WithIdempotencyKey, if any
+func idempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// DefaultIdempotencyKey derives a settlement key from what makes a payment
+// unique: the authorization nonce and payee for EVM, the owner and permit
+// nonce for EIP-2612 permits, the transaction signature for Solana. It
+// returns "" when the header carries none of them.
+func DefaultIdempotencyKey(header PaymentHeader, requirements PaymentRequirements) string {
+	var id string
+	switch {
+	case header.Payload.Authorization != nil:
+		id = header.Payload.Authorization.Nonce
+	case header.Payload.Permit != nil:
+		id = strings.ToLower(header.Payload.Permit.Owner) + ":" + header.Payload.Permit.Nonce
+	case len(header.Payload.Signatures) > 0:
+		id = header.Payload.Signatures[0]
+	default:
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(id + ":" + strings.ToLower(requirements.PayTo)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package nova402
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// settleKeyServer fails the first two settle requests with 503, records the
+// idempotency key of every request and then reports success
+func settleKeyServer(keys *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*keys = append(*keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(*keys) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+}
+
+func TestSettleReusesIdempotencyKey(t *testing.T) {
+	var keys []string
+	fac := settleKeyServer(&keys)
+	defer fac.Close()
+
+	c := NewClient("base-sepolia", fac.URL, WithRetries(3, time.Millisecond))
+	header := PaymentHeader{Payload: PaymentPayload{Authorization: &EIP3009Authorization{Nonce: "0x01"}}}
+	requirements := PaymentRequirements{PayTo: "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"}
+	if _, err := c.Settle(header, requirements); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+
+	want := DefaultIdempotencyKey(header, requirements)
+	if len(keys) != 3 || want == "" {
+		t.Fatalf("facilitator saw keys %q, want 3 attempts", keys)
+	}
+	for i, key := range keys {
+		if key != want {
+			t.Fatalf("attempt %d sent key %q, want %q", i+1, key, want)
+		}
+	}
+}
+
+func TestSettleIdempotencyKeyFromContext(t *testing.T) {
+	var keys []string
+	fac := settleKeyServer(&keys)
+	defer fac.Close()
+
+	c := NewClient("base-sepolia", fac.URL, WithRetries(3, time.Millisecond))
+	header := PaymentHeader{Payload: PaymentPayload{Authorization: &EIP3009Authorization{Nonce: "0x01"}}}
+	ctx := WithIdempotencyKey(context.Background(), "order-42")
+	if _, err := c.SettleWithContext(ctx, header, PaymentRequirements{}); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("facilitator saw keys %q, want 3 attempts", keys)
+	}
+	for i, key := range keys {
+		if key != "order-42" {
+			t.Fatalf("attempt %d sent key %q, want %q", i+1, key, "order-42")
+		}
+	}
+}
+
+func TestDefaultIdempotencyKeyDependsOnPayee(t *testing.T) {
+	header := PaymentHeader{Payload: PaymentPayload{Authorization: &EIP3009Authorization{Nonce: "0x01"}}}
+	a := DefaultIdempotencyKey(header, PaymentRequirements{PayTo: "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"})
+	b := DefaultIdempotencyKey(header, PaymentRequirements{PayTo: "0x209693bc6afc0c5328ba36faf03c514ef312287c"})
+	c := DefaultIdempotencyKey(header, PaymentRequirements{PayTo: "0x1111111111111111111111111111111111111111"})
+	if a != b {
+		t.Fatal("key depends on the case of the payee address")
+	}
+	if a == c {
+		t.Fatal("different payees share a key")
+	}
+	if key := DefaultIdempotencyKey(PaymentHeader{}, PaymentRequirements{}); key != "" {
+		t.Fatalf("empty payment key = %q, want empty", key)
+	}
+}
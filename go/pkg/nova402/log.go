@@ -0,0 +1,84 @@
+package nova402
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardLogger is used when no Logger is configured
+var discardLogger = slog.New(discardHandler{})
+
+// discardHandler is a slog.Handler that drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// logger returns the configured Logger, or one that discards everything
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return discardLogger
+}
+
+// requirementAttrs describes requirements for logging
+func requirementAttrs(requirements PaymentRequirements) slog.Attr {
+	return slog.Group("requirements",
+		slog.String("scheme", requirements.Scheme),
+		slog.String("network", requirements.Network),
+		slog.String("amount", requirements.MaxAmountRequired),
+		slog.String("payTo", requirements.PayTo),
+		slog.String("asset", requirements.Asset),
+	)
+}
+
+// LogValue redacts the private key when a Client is logged
+func (c *Client) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("network", c.Network),
+		slog.String("facilitatorURL", c.FacilitatorURL),
+		slog.Bool("hasPrivateKey", c.PrivateKey != ""),
+	)
+}
+
+// LogValue omits the signature when an authorization is logged
+func (a EIP3009Authorization) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("from", a.From),
+		slog.String("to", a.To),
+		slog.String("value", a.Value),
+		slog.Int64("validAfter", a.ValidAfter),
+		slog.Int64("validBefore", a.ValidBefore),
+		slog.String("nonce", a.Nonce),
+	)
+}
+
+// LogValue omits the signature when a permit is logged
+func (p EIP2612Permit) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("owner", p.Owner),
+		slog.String("spender", p.Spender),
+		slog.String("value", p.Value),
+		slog.String("nonce", p.Nonce),
+		slog.Int64("deadline", p.Deadline),
+	)
+}
+
+// LogValue omits the signed payload when a payment header is logged
+func (h PaymentHeader) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("x402Version", h.X402Version),
+		slog.String("scheme", h.Scheme),
+		slog.String("network", h.Network),
+	}
+	if h.Payload.Authorization != nil {
+		attrs = append(attrs, slog.Any("authorization", *h.Payload.Authorization))
+	}
+	if h.Payload.Permit != nil {
+		attrs = append(attrs, slog.Any("permit", *h.Payload.Permit))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,61 @@
+package nova402
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLoggingRedactsSecrets(t *testing.T) {
+	var mu sync.Mutex
+	var encodedPayment string
+	var payment *PaymentHeader
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoded := r.Header.Get("X-PAYMENT")
+		if encoded == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		mu.Lock()
+		encodedPayment = encoded
+		payment, _ = DecodePaymentHeader(encoded)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewClient("base-sepolia", "", WithLogger(logger)).WithPrivateKey(testKey)
+	resp, err := c.Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("client", slog.Any("client", c))
+
+	out := buf.String()
+	if !strings.Contains(out, "x402:") {
+		t.Fatalf("no x402 debug logs were written:\n%s", out)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if payment == nil || payment.Payload.Authorization == nil {
+		t.Fatal("server received no authorization")
+	}
+	secrets := map[string]string{
+		"private key": strings.TrimPrefix(testKey, "0x"),
+		"signature r": strings.TrimPrefix(payment.Payload.Authorization.R, "0x"),
+		"signature s": strings.TrimPrefix(payment.Payload.Authorization.S, "0x"),
+		"X-PAYMENT":   encodedPayment,
+	}
+	for name, secret := range secrets {
+		if secret != "" && strings.Contains(out, secret) {
+			t.Fatalf("logs contain the %s:\n%s", name, out)
+		}
+	}
+}
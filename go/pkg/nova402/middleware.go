@@ -0,0 +1,117 @@
+package nova402
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Verifier checks a decoded X-PAYMENT header against payment requirements.
+// *Client implements Verifier by calling its facilitator.
+type Verifier interface {
+	Verify(header PaymentHeader, requirements PaymentRequirements) (*VerificationResult, error)
+}
+
+// RequirementsFunc computes the payment requirements for a single request,
+// allowing prices to vary per request
+type RequirementsFunc func(r *http.Request) (PaymentRequirements, error)
+
+// PaymentMiddleware protects a handler with fixed payment requirements
+func PaymentMiddleware(requirements PaymentRequirements, verifier Verifier) func(http.Handler) http.Handler {
+	return PaymentMiddlewareFunc(func(*http.Request) (PaymentRequirements, error) {
+		return requirements, nil
+	}, verifier)
+}
+
+// PaymentMiddlewareFunc protects a handler with requirements computed per request.
+// Requests without a valid X-PAYMENT header receive a 402 listing the
+// requirements; verified requests are passed to the next handler.
+func PaymentMiddlewareFunc(requirementsFn RequirementsFunc, verifier Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requirements, err := requirementsFn(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if requirements.X402Version == 0 {
+				requirements.X402Version = X402Version
+			}
+			if requirements.Resource == "" {
+				requirements.Resource = r.URL.String()
+			}
+
+			encoded := r.Header.Get("X-PAYMENT")
+			if encoded == "" {
+				writePaymentRequired(w, requirements, "X-PAYMENT header is required")
+				return
+			}
+
+			header, err := ParsePaymentHeader(encoded)
+			if err != nil {
+				writePaymentRequired(w, requirements, err.Error())
+				return
+			}
+
+			result, err := verifier.Verify(*header, requirements)
+			var invalid *VerificationError
+			if err != nil && !errors.As(err, &invalid) {
+				http.Error(w, "payment verification failed", http.StatusBadGateway)
+				return
+			}
+			if invalid != nil && result == nil {
+				result = invalid.Result
+			}
+			if result == nil || !result.IsValid {
+				reason := "invalid payment"
+				if result != nil && result.InvalidReason != nil {
+					reason = *result.InvalidReason
+				}
+				writePaymentRequired(w, requirements, reason)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writePaymentRequired writes a 402 response listing the accepted requirements
+func writePaymentRequired(w http.ResponseWriter, requirements PaymentRequirements, reason string) {
+	WritePayment402(w, NewPayment402Response(requirements).WithError(reason))
+}
+
+// NewPayment402Response builds the body of a 402 response accepting any of
+// the given requirements
+func NewPayment402Response(requirements ...PaymentRequirements) Payment402Response {
+	return Payment402Response{
+		X402Version: X402Version,
+		Accepts:     append([]PaymentRequirements{}, requirements...),
+	}
+}
+
+// WithError returns a copy of r carrying reason, for example why a payment
+// was rejected. An empty reason clears it.
+func (r Payment402Response) WithError(reason string) Payment402Response {
+	if reason == "" {
+		r.Error = nil
+		return r
+	}
+	r.Error = &reason
+	return r
+}
+
+// WritePayment402 writes resp as a 402 Payment Required JSON response. The
+// body is encoded before anything is written, so on error w is untouched.
+func WritePayment402(w http.ResponseWriter, resp Payment402Response) error {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal 402 response: %w", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPaymentRequired)
+	_, err = w.Write(body)
+	return err
+}
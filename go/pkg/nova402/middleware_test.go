@@ -0,0 +1,30 @@
+package nova402
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWritePayment402(t *testing.T) {
+	rec := httptest.NewRecorder()
+	requirements := PaymentRequirements{Scheme: "exact", Network: "base", MaxAmountRequired: "1", PayTo: "x"}
+	if err := WritePayment402(rec, NewPayment402Response(requirements).WithError("nope")); err != nil {
+		t.Fatalf("WritePayment402: %v", err)
+	}
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("status = %d, want 402", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+
+	var got Payment402Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding body %q: %v", rec.Body, err)
+	}
+	if got.X402Version != 1 || got.Error == nil || *got.Error != "nope" || len(got.Accepts) != 1 {
+		t.Fatalf("body = %s, want version 1, error \"nope\" and one requirement", rec.Body)
+	}
+}
@@ -0,0 +1,135 @@
+package nova402
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// A 402 response with "multi": true asks for every requirement in accepts to
+// be paid at once, for example when a fee is split between several payees.
+// The client answers with a single X-PAYMENT header whose scheme is "multi"
+// and whose payments hold a complete payment header for each requirement, in
+// the order of accepts:
+//
+//	{
+//	  "x402Version": 1,
+//	  "scheme": "multi",
+//	  "network": "",
+//	  "payload": {},
+//	  "payments": [
+//	    {"x402Version": 1, "scheme": "exact", "network": "base", "payload": {"authorization": {...}}},
+//	    {"x402Version": 1, "scheme": "exact", "network": "solana", "payload": {"transaction": "..."}}
+//	  ]
+//	}
+//
+// Each part is signed on its own, so Solana parts are separate transactions
+// and a server verifies and settles each part against its requirement.
+
+// parts returns the payments h makes: its Payments for a multi payment,
+// otherwise h itself
+func (h *PaymentHeader) parts() []PaymentHeader {
+	if PaymentScheme(h.Scheme) == SchemeMulti {
+		return h.Payments
+	}
+	return []PaymentHeader{*h}
+}
+
+// partRequirements returns the requirement paid by the i'th part of a
+// payment, or nil when it is unknown
+func partRequirements(requirements []PaymentRequirements, i int) *PaymentRequirements {
+	if i < len(requirements) {
+		return &requirements[i]
+	}
+	return nil
+}
+
+// prepareMultiPayment checks every requirement of a multi 402 response and
+// signs a payment for each. Per-payment limits apply to each part, while
+// spend caps and balances are checked against the parts' combined amounts.
+func (c *Client) prepareMultiPayment(ctx context.Context, version int, accepts []PaymentRequirements) (*PaymentHeader, []PaymentRequirements, error) {
+	if len(accepts) > MaxMultiPayments {
+		return nil, nil, fmt.Errorf("%w: multi payment of %d requirements exceeds the limit of %d", ErrInvalidPaymentRequired, len(accepts), MaxMultiPayments)
+	}
+
+	requirements := make([]PaymentRequirements, len(accepts))
+	networkTotals := make(map[string]*big.Int)
+	assetTotals := make(map[string]*big.Int)
+	assetRequirements := make(map[string]PaymentRequirements)
+	for i, r := range accepts {
+		if r.X402Version == 0 {
+			r.X402Version = version
+		}
+		if err := c.checkNetwork(r.Network); err != nil {
+			return nil, nil, fmt.Errorf("accepts[%d]: %w", i, err)
+		}
+		if err := r.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("accepts[%d]: %w", i, err)
+		}
+		if err := c.checkPayTo(r.PayTo); err != nil {
+			return nil, nil, fmt.Errorf("accepts[%d]: %w", i, err)
+		}
+		amount, err := c.paymentValue(ctx, r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("accepts[%d]: %w", i, err)
+		}
+		if amount, err = c.drawableValue(r, amount); err != nil {
+			return nil, nil, fmt.Errorf("accepts[%d]: %w", i, err)
+		}
+		if err := c.checkPaymentLimit(r.Network, amount); err != nil {
+			return nil, nil, err
+		}
+		requirements[i] = r
+
+		value, _ := new(big.Int).SetString(amount, 10)
+		addTotal(networkTotals, canonicalNetwork(r.Network), value)
+		if c.CheckBalance && IsEVMNetwork(r.Network) {
+			asset, err := requirementsAsset(r)
+			if err != nil {
+				return nil, nil, err
+			}
+			key := canonicalNetwork(r.Network) + "|" + strings.ToLower(asset)
+			addTotal(assetTotals, key, value)
+			assetRequirements[key] = r
+		}
+	}
+
+	for network, total := range networkTotals {
+		if err := c.checkSpendCap(network, total.String()); err != nil {
+			return nil, nil, err
+		}
+	}
+	for key, total := range assetTotals {
+		if err := c.checkBalance(ctx, assetRequirements[key], total.String()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	payment := &PaymentHeader{
+		X402Version: version,
+		Scheme:      string(SchemeMulti),
+		Payments:    make([]PaymentHeader, len(requirements)),
+	}
+	for i, r := range requirements {
+		part, err := c.createPaymentHeader(ctx, r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create payment for accepts[%d]: %w", i, err)
+		}
+		payment.Payments[i] = *part
+	}
+	for i, r := range requirements {
+		if err := c.approvePayment(r, &payment.Payments[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return payment, requirements, nil
+}
+
+// addTotal adds value to totals[key]
+func addTotal(totals map[string]*big.Int, key string, value *big.Int) {
+	if totals[key] == nil {
+		totals[key] = new(big.Int)
+	}
+	totals[key].Add(totals[key], value)
+}
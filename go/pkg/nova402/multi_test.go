@@ -0,0 +1,66 @@
+package nova402
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestMultiPayment(t *testing.T) {
+	first := testRequirements()
+	first.Resource = "x"
+	second := first
+	second.PayTo = "0x1111111111111111111111111111111111111111"
+	second.MaxAmountRequired = "50"
+
+	var mu sync.Mutex
+	var received *PaymentHeader
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if payment := r.Header.Get("X-PAYMENT"); payment != "" {
+			header, err := ParsePaymentHeader(payment)
+			if err != nil {
+				t.Errorf("ParsePaymentHeader: %v", err)
+			}
+			mu.Lock()
+			received = header
+			mu.Unlock()
+			w.Write([]byte("ok"))
+			return
+		}
+		resp := NewPayment402Response(first, second)
+		resp.Multi = true
+		WritePayment402(w, resp)
+	}))
+	defer srv.Close()
+
+	c := NewClientWithOptions(WithNetwork("base-sepolia"), WithPrivateKey(testKey), WithDailyCap("base-sepolia", big.NewInt(1050)))
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	paid.Response.Body.Close()
+	if len(paid.MultiRequirements) != 2 || paid.Requirements != nil {
+		t.Fatalf("paid %+v, want both requirements in MultiRequirements", paid)
+	}
+
+	mu.Lock()
+	header := received
+	mu.Unlock()
+	if header == nil || header.Scheme != "multi" || len(header.Payments) != 2 {
+		t.Fatalf("server received %+v, want a multi payment of 2", header)
+	}
+	if value := header.Payments[1].Payload.Authorization.Value; value != "50" {
+		t.Fatalf("second payment value = %s, want 50", value)
+	}
+
+	// Both payments count against the cap, which the next pair would exceed
+	if total, _ := c.TotalSpent("base-sepolia"); total.String() != "1050" {
+		t.Fatalf("TotalSpent = %v, want 1050", total)
+	}
+	if _, err := c.GetPaid(context.Background(), srv.URL, nil); err == nil {
+		t.Fatal("multi payment over the daily cap succeeded")
+	}
+}
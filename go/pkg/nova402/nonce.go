@@ -0,0 +1,85 @@
+package nova402
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// nonceAttempts bounds how many fresh nonces are tried when the store reports reuse
+const nonceAttempts = 3
+
+// GenerateNonce returns a random 32-byte EIP-3009 nonce as 0x-prefixed hex
+func GenerateNonce() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return "0x" + hex.EncodeToString(buf), nil
+}
+
+// NonceStore tracks nonces already signed for each payee so none is reused
+type NonceStore interface {
+	// Reserve records nonce for payTo and reports false if it was already used
+	Reserve(payTo, nonce string) (bool, error)
+}
+
+// MemoryNonceStore is an in-memory NonceStore safe for concurrent use
+type MemoryNonceStore struct {
+	mu   sync.Mutex
+	used map[string]map[string]struct{}
+}
+
+// NewMemoryNonceStore creates an empty in-memory nonce store
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{used: make(map[string]map[string]struct{})}
+}
+
+// Reserve records nonce for payTo and reports false if it was already used
+func (s *MemoryNonceStore) Reserve(payTo, nonce string) (bool, error) {
+	payTo = strings.ToLower(payTo)
+	nonce = strings.ToLower(nonce)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nonces, ok := s.used[payTo]
+	if !ok {
+		nonces = make(map[string]struct{})
+		s.used[payTo] = nonces
+	}
+	if _, seen := nonces[nonce]; seen {
+		return false, nil
+	}
+	nonces[nonce] = struct{}{}
+	return true, nil
+}
+
+// newNonce generates a nonce for payTo with NonceFunc or GenerateNonce,
+// checking it against the client's NonceStore when one is configured
+func (c *Client) newNonce(payTo string) (string, error) {
+	generate := GenerateNonce
+	if c.NonceFunc != nil {
+		generate = c.NonceFunc
+	}
+	for i := 0; i < nonceAttempts; i++ {
+		nonce, err := generate()
+		if err != nil {
+			return "", err
+		}
+		if c.NonceStore == nil {
+			return nonce, nil
+		}
+
+		fresh, err := c.NonceStore.Reserve(payTo, nonce)
+		if err != nil {
+			return "", fmt.Errorf("failed to reserve nonce: %w", err)
+		}
+		if fresh {
+			return nonce, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate an unused nonce for %s", payTo)
+}
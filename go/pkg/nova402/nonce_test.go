@@ -0,0 +1,60 @@
+package nova402
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestNewNonceUniqueUnderConcurrency(t *testing.T) {
+	const workers, perWorker = 16, 64
+	c := NewClient("base-sepolia", "")
+	c.NonceStore = NewMemoryNonceStore()
+	payTo := "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+
+	nonces := make(chan string, workers*perWorker)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				nonce, err := c.newNonce(payTo)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				nonces <- nonce
+			}
+		}()
+	}
+	wg.Wait()
+	close(nonces)
+
+	seen := make(map[string]bool)
+	for nonce := range nonces {
+		if len(nonce) != 66 {
+			t.Fatalf("nonce %q is not 32 bytes of 0x-prefixed hex", nonce)
+		}
+		if seen[nonce] {
+			t.Fatalf("nonce %s generated twice", nonce)
+		}
+		seen[nonce] = true
+	}
+	if len(seen) != workers*perWorker {
+		t.Fatalf("got %d nonces, want %d", len(seen), workers*perWorker)
+	}
+}
+
+func TestNewNonceRetriesReusedNonce(t *testing.T) {
+	fixed := "0x0100000000000000000000000000000000000000000000000000000000000000"
+	c := NewClient("base-sepolia", "", WithNonceFunc(func() (string, error) { return fixed, nil }))
+	c.NonceStore = NewMemoryNonceStore()
+	payTo := "0x209693Bc6afc0C5328bA36FaF03C514EF312287C"
+
+	if nonce, err := c.newNonce(payTo); err != nil || nonce != fixed {
+		t.Fatalf("first newNonce = %q, %v; want %q", nonce, err, fixed)
+	}
+	if _, err := c.newNonce(payTo); err == nil {
+		t.Fatal("newNonce reused a nonce already reserved for the payee")
+	}
+}
@@ -0,0 +1,147 @@
+// Package nova402test provides an x402 resource server and a deterministic
+// signer for testing clients end to end, in the manner of net/http/httptest.
+package nova402test
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nova402/nova-utils/go/pkg/nova402"
+)
+
+// PaymentServer is an httptest.Server that answers unpaid requests with 402
+// and the configured requirements, and passes requests carrying an accepted
+// X-PAYMENT header to its handler, which replies 200 "ok" by default
+type PaymentServer struct {
+	*httptest.Server
+
+	// Requirements are the payment requirements the server advertises
+	Requirements nova402.PaymentRequirements
+
+	handler     http.Handler
+	verifier    nova402.Verifier
+	verifyLocal bool
+	mu          sync.Mutex
+	payments    []nova402.PaymentHeader
+}
+
+// Option configures a PaymentServer
+type Option func(*PaymentServer)
+
+// WithHandler serves paid requests with handler instead of replying "ok"
+func WithHandler(handler http.Handler) Option {
+	return func(s *PaymentServer) {
+		s.handler = handler
+	}
+}
+
+// WithVerifier checks payments with verifier, for example a *nova402.Client
+// pointed at a facilitator. By default any well-formed payment is accepted.
+func WithVerifier(verifier nova402.Verifier) Option {
+	return func(s *PaymentServer) {
+		s.verifier = verifier
+	}
+}
+
+// WithLocalVerification checks payments without a facilitator: the scheme
+// and network must match, and EVM authorizations must be signed by From and
+// pay Requirements.PayTo within their validity window: exactly
+// MaxAmountRequired for the exact scheme, at most that for others
+func WithLocalVerification() Option {
+	return func(s *PaymentServer) {
+		s.verifyLocal = true
+	}
+}
+
+// NewPaymentServer starts a server protecting every path with requirements.
+// The caller should call Close when finished.
+func NewPaymentServer(requirements nova402.PaymentRequirements, opts ...Option) *PaymentServer {
+	s := &PaymentServer{
+		Requirements: requirements,
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	middleware := nova402.PaymentMiddleware(requirements, verifierFunc(s.verify))
+	s.Server = httptest.NewServer(middleware(s.handler))
+	return s
+}
+
+// Payments returns the payment headers the server has accepted, in order
+func (s *PaymentServer) Payments() []nova402.PaymentHeader {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]nova402.PaymentHeader(nil), s.payments...)
+}
+
+// verifierFunc adapts a function to nova402.Verifier
+type verifierFunc func(header nova402.PaymentHeader, requirements nova402.PaymentRequirements) (*nova402.VerificationResult, error)
+
+func (f verifierFunc) Verify(header nova402.PaymentHeader, requirements nova402.PaymentRequirements) (*nova402.VerificationResult, error) {
+	return f(header, requirements)
+}
+
+// verify runs the configured checks and records accepted payments
+func (s *PaymentServer) verify(header nova402.PaymentHeader, requirements nova402.PaymentRequirements) (*nova402.VerificationResult, error) {
+	result := &nova402.VerificationResult{IsValid: true}
+	if s.verifyLocal {
+		if reason := verifyLocally(header, requirements, time.Now()); reason != "" {
+			result = &nova402.VerificationResult{InvalidReason: &reason}
+		}
+	}
+	if result.IsValid && s.verifier != nil {
+		var err error
+		if result, err = s.verifier.Verify(header, requirements); err != nil && result == nil {
+			return nil, err
+		}
+	}
+
+	if result.IsValid {
+		s.mu.Lock()
+		s.payments = append(s.payments, header)
+		s.mu.Unlock()
+	}
+	return result, nil
+}
+
+// verifyLocally returns why header does not satisfy requirements, or "" if it does
+func verifyLocally(header nova402.PaymentHeader, requirements nova402.PaymentRequirements, now time.Time) string {
+	if header.Scheme != requirements.Scheme {
+		return "invalid_scheme"
+	}
+	if header.Network != requirements.Network {
+		return "invalid_network"
+	}
+
+	auth := header.Payload.Authorization
+	if auth == nil {
+		return ""
+	}
+	if !strings.EqualFold(auth.To, requirements.PayTo) {
+		return "recipient_mismatch"
+	}
+	value, ok := new(big.Int).SetString(auth.Value, 10)
+	max, _ := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+	if !ok || max == nil || value.Sign() <= 0 || value.Cmp(max) > 0 ||
+		(nova402.PaymentScheme(header.Scheme) == nova402.SchemeExact && value.Cmp(max) != 0) {
+		return "amount_mismatch"
+	}
+	if now.Unix() < auth.ValidAfter {
+		return "authorization_not_yet_valid"
+	}
+	if now.Unix() >= auth.ValidBefore {
+		return "authorization_expired"
+	}
+	if valid, err := nova402.VerifyAuthorizationSignature(*auth, header.Network); err != nil || !valid {
+		return "invalid_signature"
+	}
+	return ""
+}
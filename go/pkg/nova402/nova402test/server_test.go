@@ -0,0 +1,59 @@
+package nova402test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/nova402/nova-utils/go/pkg/nova402"
+)
+
+// testKey is a throwaway private key used to sign test payments
+const testKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+func testRequirements(scheme string) nova402.PaymentRequirements {
+	return nova402.PaymentRequirements{
+		X402Version:       1,
+		Scheme:            scheme,
+		Network:           "base-sepolia",
+		MaxAmountRequired: "1000",
+		PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+		MaxTimeoutSeconds: 60,
+	}
+}
+
+func TestPaymentServer(t *testing.T) {
+	srv := NewPaymentServer(testRequirements("exact"), WithLocalVerification())
+	defer srv.Close()
+
+	c := nova402.NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	resp, err := c.Get(srv.URL+"/x", nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Fatalf("Get = %d %q, want 200 \"ok\"", resp.StatusCode, body)
+	}
+	if payments := srv.Payments(); len(payments) != 1 {
+		t.Fatalf("server recorded %d payments, want 1", len(payments))
+	}
+}
+
+func TestPaymentServerUpto(t *testing.T) {
+	srv := NewPaymentServer(testRequirements("upto"), WithLocalVerification())
+	defer srv.Close()
+
+	c := nova402.NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	c.PayAmount = "5"
+	resp, err := c.Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	payments := srv.Payments()
+	if len(payments) != 1 || payments[0].Payload.Authorization.Value != "5" {
+		t.Fatalf("server recorded %+v, want one payment of 5", payments)
+	}
+}
@@ -0,0 +1,57 @@
+package nova402test
+
+import (
+	"github.com/nova402/nova-utils/go/pkg/nova402"
+)
+
+// MockPrivateKey is the key MockSigner signs with. It is a widely published
+// development key: anyone can sign as MockAddress, and anything sent to that
+// address on a real network is lost.
+const MockPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// MockAddress is the address MockSigner reports
+const MockAddress = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+
+// MockNonce is the nonce FixedNonce(MockNonce) returns, for tests that do not
+// care which fixed nonce they use
+const MockNonce = "0x0000000000000000000000000000000000000000000000000000000000000001"
+
+// MockSigner is a nova402.Signer for tests. Its address is always MockAddress
+// and it signs deterministically with MockPrivateKey, so the same typed data
+// always produces the same signature bytes and payments can be compared
+// byte for byte. The signatures are genuine, so they pass
+// WithLocalVerification and the client's own signature checks.
+//
+// MockSigner must never be used in production: its key is public.
+type MockSigner struct {
+	local *nova402.LocalSigner
+}
+
+// NewMockSigner creates a MockSigner
+func NewMockSigner() *MockSigner {
+	local, err := nova402.NewLocalSigner(MockPrivateKey)
+	if err != nil {
+		panic("nova402test: invalid mock private key: " + err.Error())
+	}
+	return &MockSigner{local: local}
+}
+
+// Address returns MockAddress
+func (s *MockSigner) Address() (string, error) {
+	return MockAddress, nil
+}
+
+// SignTypedData signs the EIP-712 digest of message with MockPrivateKey
+func (s *MockSigner) SignTypedData(domain nova402.EIP712Domain, message nova402.TypedData) ([]byte, error) {
+	return s.local.SignTypedData(domain, message)
+}
+
+// FixedNonce returns a nonce function for nova402.WithNonceFunc that always
+// yields nonce, so with MockSigner the nonce and signer of every payment are
+// known in advance. A client with a NonceStore refuses the nonce the second
+// time it pays the same payee. Never use it in production.
+func FixedNonce(nonce string) func() (string, error) {
+	return func() (string, error) {
+		return nonce, nil
+	}
+}
@@ -0,0 +1,44 @@
+package nova402test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nova402/nova-utils/go/pkg/nova402"
+)
+
+func TestMockSignerIsDeterministic(t *testing.T) {
+	srv := NewPaymentServer(testRequirements("exact"), WithLocalVerification())
+	defer srv.Close()
+
+	c := nova402.NewClientWithOptions(
+		nova402.WithNetwork("base-sepolia"),
+		nova402.WithSigner(NewMockSigner()),
+		nova402.WithNonceFunc(FixedNonce(MockNonce)),
+		nova402.WithSignatureVerification(true),
+	)
+	resp, err := c.Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	payments := srv.Payments()
+	if len(payments) != 1 {
+		t.Fatalf("server recorded %d payments, want 1", len(payments))
+	}
+	auth := payments[0].Payload.Authorization
+	if auth.Nonce != MockNonce || auth.From != MockAddress {
+		t.Fatalf("authorization from %s with nonce %s, want %s and %s", auth.From, auth.Nonce, MockAddress, MockNonce)
+	}
+}
+
+func TestMockSignerAddress(t *testing.T) {
+	address, err := NewMockSigner().Address()
+	if err != nil || address != MockAddress {
+		t.Fatalf("Address = %q, %v; want %s", address, err, MockAddress)
+	}
+}
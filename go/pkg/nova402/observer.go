@@ -0,0 +1,34 @@
+package nova402
+
+import "time"
+
+// Observer receives payment flow events for metrics. Calls are made
+// synchronously on the request path, so implementations should be fast.
+// Embed NoopObserver to implement only the events you need.
+type Observer interface {
+	// OnPaymentRequired is called when a resource answers 402
+	OnPaymentRequired(url string)
+	// OnVerify is called after each facilitator verification, including retries
+	OnVerify(duration time.Duration, err error)
+	// OnSettle is called after each settlement, including retries
+	OnSettle(duration time.Duration, err error)
+	// OnPaymentCompleted is called when a paid request is accepted, with the
+	// base-unit amount paid
+	OnPaymentCompleted(amount, network string)
+}
+
+// NoopObserver ignores every event
+type NoopObserver struct{}
+
+func (NoopObserver) OnPaymentRequired(string)                  {}
+func (NoopObserver) OnVerify(time.Duration, error)             {}
+func (NoopObserver) OnSettle(time.Duration, error)             {}
+func (NoopObserver) OnPaymentCompleted(amount, network string) {}
+
+// observer returns the configured Observer, or one that ignores everything
+func (c *Client) observer() Observer {
+	if c.Observer != nil {
+		return c.Observer
+	}
+	return NoopObserver{}
+}
@@ -0,0 +1,75 @@
+package nova402
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingObserver records the events it is notified of
+type recordingObserver struct {
+	mu        sync.Mutex
+	required  []string
+	verified  []error
+	completed []string
+}
+
+func (o *recordingObserver) OnPaymentRequired(url string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.required = append(o.required, url)
+}
+
+func (o *recordingObserver) OnVerify(_ time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.verified = append(o.verified, err)
+}
+
+func (o *recordingObserver) OnSettle(time.Duration, error) {}
+
+func (o *recordingObserver) OnPaymentCompleted(amount, network string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.completed = append(o.completed, amount+" "+network)
+}
+
+func TestObserverSeesPaidRequest(t *testing.T) {
+	srv := paidServer(nil)
+	defer srv.Close()
+
+	observer := &recordingObserver{}
+	c := NewClient("base-sepolia", "", WithObserver(observer)).WithPrivateKey(testKey)
+	resp, err := c.Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	if len(observer.required) != 1 || observer.required[0] != srv.URL {
+		t.Fatalf("OnPaymentRequired calls = %v, want [%s]", observer.required, srv.URL)
+	}
+	if len(observer.completed) != 1 || observer.completed[0] != "1000 base-sepolia" {
+		t.Fatalf("OnPaymentCompleted calls = %v, want [1000 base-sepolia]", observer.completed)
+	}
+}
+
+func TestObserverSeesVerifyRetries(t *testing.T) {
+	fac := failingFacilitator(503, 1, new(atomic.Int32))
+	defer fac.Close()
+
+	observer := &recordingObserver{}
+	c := NewClient("base-sepolia", fac.URL, WithObserver(observer), WithRetries(1, time.Millisecond))
+	if _, err := c.Verify(PaymentHeader{}, PaymentRequirements{}); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	if len(observer.verified) != 2 || observer.verified[0] == nil || observer.verified[1] != nil {
+		t.Fatalf("OnVerify errors = %v, want a failure then a success", observer.verified)
+	}
+}
@@ -0,0 +1,417 @@
+package nova402
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientOption configures a Client at construction
+type ClientOption func(*Client)
+
+// WithNetwork sets the network the client pays on
+func WithNetwork(network string) ClientOption {
+	return func(c *Client) {
+		c.Network = network
+	}
+}
+
+// WithBaseURL sets the root that relative request URLs resolve against
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.BaseURL = baseURL
+	}
+}
+
+// WithFacilitatorURL sets the base URL of the HTTP facilitator
+func WithFacilitatorURL(url string) ClientOption {
+	return func(c *Client) {
+		c.FacilitatorURL = url
+	}
+}
+
+// WithDiscoveryURL sets the service directory endpoint
+func WithDiscoveryURL(url string) ClientOption {
+	return func(c *Client) {
+		c.DiscoveryURL = url
+	}
+}
+
+// WithFaucetURL sets the test token faucet used by RequestFaucet
+func WithFaucetURL(url string) ClientOption {
+	return func(c *Client) {
+		c.FaucetURL = url
+	}
+}
+
+// WithComputeUnitPrice adds a priority fee of microLamports per compute unit
+// to Solana payment transactions
+func WithComputeUnitPrice(microLamports uint64) ClientOption {
+	return func(c *Client) {
+		c.ComputeUnitPrice = microLamports
+	}
+}
+
+// WithComputeUnitLimit caps the compute units a Solana payment transaction
+// may use
+func WithComputeUnitLimit(units uint32) ClientOption {
+	return func(c *Client) {
+		c.ComputeUnitLimit = units
+	}
+}
+
+// WithFacilitator replaces the HTTP facilitator with a custom implementation
+func WithFacilitator(facilitator Facilitator) ClientOption {
+	return func(c *Client) {
+		c.Facilitator = facilitator
+	}
+}
+
+// WithPrivateKey sets the raw private key used to sign payments. It cannot be
+// combined with WithSigner or WithSolanaSigner.
+func WithPrivateKey(privateKey string) ClientOption {
+	return func(c *Client) {
+		c.PrivateKey = privateKey
+	}
+}
+
+// WithSigner sets the signer for EVM payment authorizations
+func WithSigner(signer Signer) ClientOption {
+	return func(c *Client) {
+		c.Signer = signer
+	}
+}
+
+// WithContractWallet pays from the smart-contract wallet at address, whose
+// EIP-1271 isValidSignature accepts the Signer's signatures
+func WithContractWallet(address string) ClientOption {
+	return func(c *Client) {
+		c.ContractWallet = address
+	}
+}
+
+// WithSolanaSigner sets the signer for Solana payment transactions
+func WithSolanaSigner(signer SolanaSigner) ClientOption {
+	return func(c *Client) {
+		c.SolanaSigner = signer
+	}
+}
+
+// WithSignatureVerification checks each signed EVM authorization or Solana
+// transaction against the payer before it is sent
+func WithSignatureVerification(verify bool) ClientOption {
+	return func(c *Client) {
+		c.VerifySignatures = verify
+	}
+}
+
+// WithNonceFunc makes the client take EIP-3009 nonces from fn instead of
+// GenerateNonce. It is meant for tests only; see Client.NonceFunc.
+func WithNonceFunc(fn func() (string, error)) ClientOption {
+	return func(c *Client) {
+		c.NonceFunc = fn
+	}
+}
+
+// WithRetries retries transient failures up to maxRetries times, starting
+// from backoff. A zero backoff uses DefaultRetryBackoff.
+func WithRetries(maxRetries int, backoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.MaxRetries = maxRetries
+		c.RetryBackoff = backoff
+	}
+}
+
+// WithOperationTimeout bounds each paid request end to end, including the
+// 402 round trip, retries and reading the response body
+func WithOperationTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.OperationTimeout = timeout
+	}
+}
+
+// WithRateLimit limits paid requests and facilitator calls to rps per second
+// with bursts of up to burst, waiting for capacity rather than failing
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return func(c *Client) {
+		c.RateLimit = rps
+		c.RateBurst = burst
+	}
+}
+
+// WithSpendTracker records spend in tracker, which may be shared between
+// clients. A nil tracker disables spend tracking.
+func WithSpendTracker(tracker *SpendTracker) ClientOption {
+	return func(c *Client) {
+		c.SpendTracker = tracker
+	}
+}
+
+// WithValidityBuffer backdates validAfter by buffer instead of
+// DefaultValidityBuffer, for clients whose clock is skewed from the chain's
+func WithValidityBuffer(buffer time.Duration) ClientOption {
+	return func(c *Client) {
+		c.ValidityBuffer = buffer
+	}
+}
+
+// WithStrictDecoding rejects 402 responses with unknown fields
+func WithStrictDecoding(strict bool) ClientOption {
+	return func(c *Client) {
+		c.StrictDecoding = strict
+	}
+}
+
+// WithRedirectPolicy sets how paid requests follow redirects
+func WithRedirectPolicy(policy RedirectPolicy) ClientOption {
+	return func(c *Client) {
+		c.RedirectPolicy = policy
+	}
+}
+
+// WithRequirementSelector sets how the client picks among accepted requirements
+func WithRequirementSelector(selector RequirementSelector) ClientOption {
+	return func(c *Client) {
+		c.RequirementSelector = selector
+	}
+}
+
+// WithAllowedNetworks lets the client pay on networks besides its own.
+// Pass "*" to allow any network.
+func WithAllowedNetworks(networks ...string) ClientOption {
+	return func(c *Client) {
+		c.AllowedNetworks = append(c.AllowedNetworks, networks...)
+	}
+}
+
+// WithPayToAllowlist restricts the client to paying the given addresses
+func WithPayToAllowlist(addresses []string) ClientOption {
+	return func(c *Client) {
+		c.PayToAllowlist = append(c.PayToAllowlist, addresses...)
+	}
+}
+
+// WithPayToDenylist stops the client from paying the given addresses
+func WithPayToDenylist(addresses []string) ClientOption {
+	return func(c *Client) {
+		c.PayToDenylist = append(c.PayToDenylist, addresses...)
+	}
+}
+
+// WithMaxPaymentAmount caps the base-unit amount paid per request on network,
+// which may be given by an alias. A nil or zero amount removes the cap.
+func WithMaxPaymentAmount(network string, amount *big.Int) ClientOption {
+	network = canonicalNetwork(network)
+	return func(c *Client) {
+		if c.MaxPaymentAmount == nil {
+			c.MaxPaymentAmount = make(map[string]*big.Int)
+		}
+		c.MaxPaymentAmount[network] = amount
+	}
+}
+
+// WithDailyCap caps the base-unit amount paid on network, which may be given
+// by an alias, per CapInterval, one day by default. A nil amount removes the
+// cap.
+func WithDailyCap(network string, cap *big.Int) ClientOption {
+	network = canonicalNetwork(network)
+	return func(c *Client) {
+		if c.DailyCaps == nil {
+			c.DailyCaps = make(map[string]*big.Int)
+		}
+		if cap == nil {
+			delete(c.DailyCaps, network)
+			return
+		}
+		c.DailyCaps[network] = cap
+	}
+}
+
+// WithCapInterval sets the window DailyCaps apply to
+func WithCapInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.CapInterval = interval
+	}
+}
+
+// WithApprovalFunc asks fn to approve each signed payment before it is sent;
+// an error from fn aborts the payment
+func WithApprovalFunc(fn func(PaymentRequirements, EIP3009Authorization) error) ClientOption {
+	return func(c *Client) {
+		c.ApprovalFunc = fn
+	}
+}
+
+// WithSettlementMode selects facilitator or direct settlement
+func WithSettlementMode(mode SettlementMode) ClientOption {
+	return func(c *Client) {
+		c.SettlementMode = mode
+	}
+}
+
+// WithLogger sets the structured logger for payment flow events
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.Logger = logger
+	}
+}
+
+// WithObserver sets the observer notified of payment events
+func WithObserver(observer Observer) ClientOption {
+	return func(c *Client) {
+		c.Observer = observer
+	}
+}
+
+// WithDryRun signs payments without sending or settling them
+func WithDryRun(dryRun bool) ClientOption {
+	return func(c *Client) {
+		c.DryRun = dryRun
+	}
+}
+
+// WithBatchConcurrency bounds how many GetBatch requests run at once
+func WithBatchConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		c.BatchConcurrency = n
+	}
+}
+
+// WithRequirementsCache caches 402 responses in memory for ttl
+func WithRequirementsCache(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.RequirementsCache = NewMemoryRequirementsCache()
+		c.RequirementsCacheTTL = ttl
+	}
+}
+
+// WithUptoAuthorizationReuse reuses one authorization for "upto" payments to
+// the same payee and asset until budget base units are committed or it expires
+func WithUptoAuthorizationReuse(budget string) ClientOption {
+	return func(c *Client) {
+		c.UptoReuseBudget = budget
+	}
+}
+
+// WithPaymentStore records every payment the client sends in store
+func WithPaymentStore(store PaymentStore) ClientOption {
+	return func(c *Client) {
+		c.PaymentStore = store
+	}
+}
+
+// WithStatusChangeHandler calls fn each time a recorded payment changes status
+func WithStatusChangeHandler(fn func(payment Payment, previous PaymentStatus)) ClientOption {
+	return func(c *Client) {
+		c.OnStatusChange = fn
+	}
+}
+
+// WithHTTPClient makes the client use httpClient for both resource and
+// facilitator requests. A nil httpClient keeps the default.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.HTTPClient = httpClient
+		}
+	}
+}
+
+// NewClientWithOptions creates a client from options without validating
+// them. Invalid settings surface as errors from the first call that uses
+// them; use NewClientWithOptionsE to reject them up front.
+func NewClientWithOptions(opts ...ClientOption) *Client {
+	return newClient(opts)
+}
+
+// NewClientWithOptionsE creates a client from options, rejecting unknown
+// networks, negative retry settings and a raw private key combined with a
+// signer
+func NewClientWithOptionsE(opts ...ClientOption) (*Client, error) {
+	c := newClient(opts)
+
+	if c.Network != "" {
+		if _, err := GetNetworkConfig(c.Network); err != nil {
+			return nil, err
+		}
+	}
+	if c.PrivateKey != "" && (c.Signer != nil || c.SolanaSigner != nil) {
+		return nil, fmt.Errorf("a private key and a signer are mutually exclusive")
+	}
+	if c.ContractWallet != "" && !isValidAddress(c.ContractWallet, NetworkTypeEVM) {
+		return nil, fmt.Errorf("invalid contract wallet address %q", c.ContractWallet)
+	}
+	for _, address := range append(append([]string(nil), c.PayToAllowlist...), c.PayToDenylist...) {
+		if !IsValidEVMAddress(address) && !IsValidSolanaAddress(address) {
+			return nil, fmt.Errorf("invalid payee address %q in allowlist or denylist", address)
+		}
+	}
+	switch c.SettlementMode {
+	case "", SettlementModeFacilitator, SettlementModeDirect:
+	default:
+		return nil, fmt.Errorf("unknown settlement mode %q", c.SettlementMode)
+	}
+	switch c.RedirectPolicy {
+	case "", RedirectSameOrigin, RedirectStripPayment, RedirectNone:
+	default:
+		return nil, fmt.Errorf("unknown redirect policy %q", c.RedirectPolicy)
+	}
+	if c.UptoReuseBudget != "" {
+		if budget, ok := new(big.Int).SetString(c.UptoReuseBudget, 10); !ok || budget.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid upto reuse budget %q: must be a positive integer", c.UptoReuseBudget)
+		}
+	}
+	if c.MaxRetries < 0 || c.RetryBackoff < 0 {
+		return nil, fmt.Errorf("retries and backoff must not be negative")
+	}
+	if c.ValidityBuffer < 0 {
+		return nil, fmt.Errorf("validity buffer must not be negative")
+	}
+	if c.OperationTimeout < 0 {
+		return nil, fmt.Errorf("operation timeout must not be negative")
+	}
+	if c.CapInterval < 0 {
+		return nil, fmt.Errorf("cap interval must not be negative")
+	}
+	for network, limit := range c.MaxPaymentAmount {
+		if limit != nil && limit.Sign() < 0 {
+			return nil, fmt.Errorf("payment limit for %s must not be negative", network)
+		}
+	}
+	for network, limit := range c.DailyCaps {
+		if limit != nil && limit.Sign() < 0 {
+			return nil, fmt.Errorf("spend cap for %s must not be negative", network)
+		}
+	}
+	if c.BaseURL != "" {
+		if base, err := url.Parse(c.BaseURL); err != nil || !base.IsAbs() || base.Host == "" {
+			return nil, fmt.Errorf("invalid base URL %q: must be an absolute URL", c.BaseURL)
+		}
+	}
+	if c.RateLimit < 0 || c.RateBurst < 0 || math.IsNaN(c.RateLimit) || math.IsInf(c.RateLimit, 0) {
+		return nil, fmt.Errorf("rate limit and burst must be finite and not negative")
+	}
+	return c, nil
+}
+
+func newClient(opts []ClientOption) *Client {
+	c := &Client{
+		HTTPClient:   defaultHTTPClient(),
+		SpendTracker: NewSpendTracker(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// defaultHTTPClient is used when no HTTP client is supplied
+func defaultHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+	}
+}
@@ -0,0 +1,202 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingTransport counts the requests it passes to http.DefaultTransport
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestWithHTTPClientCarriesAllRequests(t *testing.T) {
+	srv := paidServer(nil)
+	defer srv.Close()
+	fac := failingFacilitator(http.StatusOK, 0, new(atomic.Int32))
+	defer fac.Close()
+
+	transport := &countingTransport{}
+	c := NewClient("base-sepolia", fac.URL, WithHTTPClient(&http.Client{Transport: transport})).WithPrivateKey(testKey)
+	resp, err := c.Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if _, err := c.Verify(PaymentHeader{}, PaymentRequirements{}); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	// The 402, the paid retry and the facilitator call
+	if n := transport.requests.Load(); n != 3 {
+		t.Fatalf("injected client carried %d requests, want 3", n)
+	}
+}
+
+func TestWithHTTPClientNil(t *testing.T) {
+	if NewClient("base-sepolia", "", WithHTTPClient(nil)).HTTPClient == nil {
+		t.Fatal("WithHTTPClient(nil) left the client without an HTTP client")
+	}
+}
+
+func TestNewClientWithOptionsE(t *testing.T) {
+	signer, err := NewLocalSigner(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClientWithOptionsE(WithNetwork("base-sepolia"), WithSigner(signer), WithRetries(2, 0))
+	if err != nil {
+		t.Fatalf("NewClientWithOptionsE: %v", err)
+	}
+	if c.Network != "base-sepolia" || c.MaxRetries != 2 || c.HTTPClient == nil {
+		t.Fatalf("client = %+v, want the options applied", c)
+	}
+	want, _ := signer.Address()
+	if got, err := c.Address(); err != nil || got != want {
+		t.Fatalf("Address = %s, %v; want %s", got, err, want)
+	}
+}
+
+func TestNewClientWithOptionsERejectsInvalid(t *testing.T) {
+	signer, err := NewLocalSigner(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, opts := range map[string][]ClientOption{
+		"unknown network":     {WithNetwork("not-a-network")},
+		"key and signer":      {WithNetwork("base-sepolia"), WithPrivateKey(testKey), WithSigner(signer)},
+		"negative retries":    {WithRetries(-1, 0)},
+		"unknown settlement":  {WithSettlementMode("carrier-pigeon")},
+		"bad contract wallet": {WithContractWallet("0x1234")},
+	} {
+		if _, err := NewClientWithOptionsE(opts...); err == nil {
+			t.Fatalf("%s: NewClientWithOptionsE succeeded, want error", name)
+		}
+	}
+}
+
+func TestNewClientWithOptionsDoesNotPanic(t *testing.T) {
+	c := NewClientWithOptions(WithNetwork("not-a-network"))
+	if c == nil || c.Network != "not-a-network" {
+		t.Fatalf("NewClientWithOptions = %+v", c)
+	}
+}
+
+func TestOperationTimeoutCoversRetries(t *testing.T) {
+	// The paid retry hangs and then fails with a retryable status, so only an
+	// overall deadline stops the client from retrying for seconds
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithOperationTimeout(300*time.Millisecond), WithRetries(5, time.Second)).WithPrivateKey(testKey)
+	start := time.Now()
+	_, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("GetPaid took %v, want it bounded by the 300ms operation timeout", elapsed)
+	}
+
+	// The timeout is per operation, so the same client still pays a fast server
+	ok := paidServer(nil)
+	defer ok.Close()
+	paid, err := c.GetPaid(context.Background(), ok.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	body, err := io.ReadAll(paid.Response.Body)
+	paid.Response.Body.Close()
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("body = %q, %v; want \"ok\"", body, err)
+	}
+}
+
+func TestWithApprovalFunc(t *testing.T) {
+	var paid atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		paid.Add(1)
+	}))
+	defer srv.Close()
+
+	denied := errors.New("denied")
+	var seen EIP3009Authorization
+	c := NewClientWithOptions(WithNetwork("base-sepolia"), WithPrivateKey(testKey), WithApprovalFunc(func(requirements PaymentRequirements, auth EIP3009Authorization) error {
+		seen = auth
+		return denied
+	}))
+	if _, err := c.GetPaid(context.Background(), srv.URL, nil); !errors.Is(err, denied) {
+		t.Fatalf("err = %v, want the approval func's error", err)
+	}
+	if seen.Value != "1000" {
+		t.Fatalf("approval func saw value %q, want the signed 1000", seen.Value)
+	}
+	if n := paid.Load(); n != 0 {
+		t.Fatalf("server received %d payments after denial, want 0", n)
+	}
+
+	c.ApprovalFunc = nil
+	paidResp, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid without an approval func: %v", err)
+	}
+	paidResp.Response.Body.Close()
+	if n := paid.Load(); n != 1 {
+		t.Fatalf("server received %d payments, want 1", n)
+	}
+}
+
+func TestPayToLists(t *testing.T) {
+	srv := paidServer(nil)
+	defer srv.Close()
+
+	c := NewClientWithOptions(WithNetwork("base-sepolia"), WithPrivateKey(testKey), WithPayToDenylist([]string{"0x209693bc6afc0c5328ba36faf03c514ef312287c"}))
+	if _, err := c.GetPaid(context.Background(), srv.URL, nil); !errors.Is(err, ErrPayToNotAllowed) {
+		t.Fatalf("denylisted payee: err = %v, want ErrPayToNotAllowed", err)
+	}
+	c = NewClientWithOptions(WithNetwork("base-sepolia"), WithPrivateKey(testKey), WithPayToAllowlist([]string{"0x1111111111111111111111111111111111111111"}))
+	if _, err := c.GetPaid(context.Background(), srv.URL, nil); !errors.Is(err, ErrPayToNotAllowed) {
+		t.Fatalf("payee missing from the allowlist: err = %v, want ErrPayToNotAllowed", err)
+	}
+
+	// Addresses match regardless of case
+	c = NewClientWithOptions(WithNetwork("base-sepolia"), WithPrivateKey(testKey), WithPayToAllowlist([]string{"0x209693BC6AFC0C5328BA36FAF03C514EF312287C"}))
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("allowlisted payee: %v", err)
+	}
+	paid.Response.Body.Close()
+}
+
+func TestPayToListsRejectInvalidAddresses(t *testing.T) {
+	if _, err := NewClientWithOptionsE(WithPayToAllowlist([]string{"nope"})); err == nil {
+		t.Fatal("invalid allowlist address accepted")
+	}
+}
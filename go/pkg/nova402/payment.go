@@ -0,0 +1,40 @@
+package nova402
+
+import "time"
+
+// ValidityWindow returns the validAfter and validBefore unix timestamps for an
+// authorization created at now. validAfter is backdated by DefaultValidityBuffer
+// to tolerate clock skew; validBefore allows MaxTimeoutSeconds, or
+// DefaultTimeoutSeconds when the requirements leave it unset.
+func (r PaymentRequirements) ValidityWindow(now time.Time) (validAfter, validBefore int64) {
+	timeout := r.MaxTimeoutSeconds
+	if timeout <= 0 {
+		timeout = DefaultTimeoutSeconds
+	}
+	return now.Unix() - DefaultValidityBuffer, now.Unix() + int64(timeout)
+}
+
+// validityWindow is ValidityWindow with validAfter backdated by the client's
+// ValidityBuffer when one is set
+func (c *Client) validityWindow(requirements PaymentRequirements, now time.Time) (validAfter, validBefore int64) {
+	validAfter, validBefore = requirements.ValidityWindow(now)
+	if c.ValidityBuffer > 0 {
+		validAfter = now.Unix() - int64(c.ValidityBuffer/time.Second)
+	}
+	return validAfter, validBefore
+}
+
+// NewPayment builds a pending Payment record for an authorization created at
+// now. ExpiresAt matches the authorization's ValidBefore.
+func NewPayment(requirements PaymentRequirements, auth EIP3009Authorization, now time.Time) Payment {
+	return Payment{
+		ID:        auth.Nonce,
+		From:      auth.From,
+		To:        auth.To,
+		Amount:    auth.Value,
+		Network:   requirements.Network,
+		Status:    StatusPending,
+		CreatedAt: now,
+		ExpiresAt: time.Unix(auth.ValidBefore, 0),
+	}
+}
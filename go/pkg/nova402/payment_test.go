@@ -0,0 +1,37 @@
+package nova402
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestValidityWindow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	after, before := PaymentRequirements{MaxTimeoutSeconds: 60}.ValidityWindow(now)
+	if after != now.Unix()-DefaultValidityBuffer || before != now.Unix()+60 {
+		t.Fatalf("ValidityWindow = (%d, %d), want (%d, %d)", after, before, now.Unix()-DefaultValidityBuffer, now.Unix()+60)
+	}
+}
+
+func TestValidityWindowZeroTimeout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, timeout := range []int{0, -5} {
+		_, before := PaymentRequirements{MaxTimeoutSeconds: timeout}.ValidityWindow(now)
+		if want := now.Unix() + DefaultTimeoutSeconds; before != want {
+			t.Fatalf("MaxTimeoutSeconds %d: validBefore = %d, want %d", timeout, before, want)
+		}
+	}
+}
+
+func TestWithValidityBuffer(t *testing.T) {
+	c := NewClient("base-sepolia", "", WithValidityBuffer(5*time.Minute)).WithPrivateKey(testKey)
+	header, err := c.createPaymentHeader(context.Background(), testRequirements())
+	if err != nil {
+		t.Fatalf("createPaymentHeader: %v", err)
+	}
+	if d := time.Now().Unix() - header.Payload.Authorization.ValidAfter; d < 299 || d > 301 {
+		t.Fatalf("validAfter is %ds before now, want the 300s buffer", d)
+	}
+}
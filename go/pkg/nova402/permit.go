@@ -0,0 +1,173 @@
+package nova402
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// noncesSelector is the EIP-2612 nonces(address) function selector
+const noncesSelector = "7ecebe00"
+
+// AuthTypePermit selects an EIP-2612 permit for tokens without EIP-3009. The
+// client signs a permit letting extra["spender"], normally the facilitator,
+// move the amount, and the facilitator settles with permit followed by
+// transferFrom to payTo.
+const AuthTypePermit = "permit"
+
+// permitType is the EIP-2612 Permit struct
+var permitType = []TypedDataField{
+	{Name: "owner", Type: "address"},
+	{Name: "spender", Type: "address"},
+	{Name: "value", Type: "uint256"},
+	{Name: "nonce", Type: "uint256"},
+	{Name: "deadline", Type: "uint256"},
+}
+
+// permitSpender returns the checksummed spender a permit is granted to, taken
+// from extra["spender"]
+func permitSpender(requirements PaymentRequirements) (string, error) {
+	spender, _ := requirements.Extra["spender"].(string)
+	if spender == "" {
+		return "", fmt.Errorf("%w: permit requires extra.spender", ErrInvalidRequirements)
+	}
+	checksummed, err := ToChecksumAddress(spender)
+	if err != nil {
+		return "", fmt.Errorf("%w: spender: %v", ErrInvalidRequirements, err)
+	}
+	return checksummed, nil
+}
+
+// buildPermit signs an EIP-2612 permit for the requirements' amount, valid
+// until deadline
+func (c *Client) buildPermit(ctx context.Context, requirements PaymentRequirements, deadline int64) (*EIP2612Permit, error) {
+	spender, err := permitSpender(requirements)
+	if err != nil {
+		return nil, err
+	}
+	if c.ContractWallet != "" {
+		return nil, fmt.Errorf("%w: permits cannot be signed for a contract wallet", ErrInvalidRequirements)
+	}
+	value, err := c.paymentValue(ctx, requirements)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := c.signer()
+	if err != nil {
+		return nil, err
+	}
+	owner, err := signer.Address()
+	if err != nil {
+		return nil, err
+	}
+	if owner, err = ToChecksumAddress(owner); err != nil {
+		return nil, fmt.Errorf("signer address: %w", err)
+	}
+
+	token, err := requirementsAsset(requirements)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := c.permitNonce(ctx, token, owner, requirements.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := authorizationDomain(requirements)
+	if err != nil {
+		return nil, err
+	}
+	permit := &EIP2612Permit{
+		Owner:    owner,
+		Spender:  spender,
+		Value:    value,
+		Nonce:    nonce.String(),
+		Deadline: deadline,
+	}
+	sig, err := signer.SignTypedData(domain, permitTypedData(permit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign permit: %w", err)
+	}
+	if permit.V, permit.R, permit.S, err = splitSignature(sig); err != nil {
+		return nil, err
+	}
+	return permit, nil
+}
+
+// permitTypedData returns permit as EIP-712 Permit typed data
+func permitTypedData(permit *EIP2612Permit) TypedData {
+	value, _ := new(big.Int).SetString(permit.Value, 10)
+	nonce, _ := new(big.Int).SetString(permit.Nonce, 10)
+	return TypedData{
+		PrimaryType: "Permit",
+		Types: map[string][]TypedDataField{
+			"Permit": permitType,
+		},
+		Message: map[string]interface{}{
+			"owner":    permit.Owner,
+			"spender":  permit.Spender,
+			"value":    value,
+			"nonce":    nonce,
+			"deadline": big.NewInt(permit.Deadline),
+		},
+	}
+}
+
+// permitNonce reads the owner's current EIP-2612 nonce from token. A token
+// without nonces(address) cannot be paid with either EIP-3009 or a permit, so
+// the failure is reported as ErrUnsupportedToken.
+func (c *Client) permitNonce(ctx context.Context, token, owner, network string) (*big.Int, error) {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return nil, err
+	}
+
+	data := "0x" + noncesSelector + strings.Repeat("0", 24) + strings.ToLower(owner[2:])
+	call := map[string]string{"to": token, "data": data}
+
+	var result string
+	if err := c.callRPC(ctx, config, "eth_call", []interface{}{call, "latest"}, &result); err != nil {
+		return nil, fmt.Errorf("%w: %s has no EIP-2612 nonces: %v", ErrUnsupportedToken, token, err)
+	}
+	raw, err := hexutil.Decode(result)
+	if err != nil || len(raw) != 32 {
+		return nil, fmt.Errorf("%w: %s has no EIP-2612 nonces", ErrUnsupportedToken, token)
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// evmPayload signs the EVM payload the requirements ask for: an EIP-2612
+// permit, or an EIP-3009 authorization, reusing one for upto payments when
+// configured
+func (c *Client) evmPayload(ctx context.Context, requirements PaymentRequirements) (PaymentPayload, error) {
+	authType, err := authorizationType(requirements)
+	if err != nil {
+		return PaymentPayload{}, err
+	}
+
+	validAfter, validBefore := c.validityWindow(requirements, time.Now())
+	if authType == AuthTypePermit {
+		permit, err := c.buildPermit(ctx, requirements, validBefore)
+		if err != nil {
+			return PaymentPayload{}, err
+		}
+		return PaymentPayload{Permit: permit}, nil
+	}
+
+	auth, err := c.uptoAuthorization(ctx, requirements, time.Now())
+	if err != nil {
+		return PaymentPayload{}, err
+	}
+	if auth == nil {
+		auth, err = c.buildAuthorization(ctx, requirements, validAfter, validBefore)
+		if err != nil {
+			return PaymentPayload{}, err
+		}
+	}
+	return PaymentPayload{Authorization: auth}, nil
+}
@@ -0,0 +1,85 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// permitRequirements returns EIP-2612 permit requirements on network
+func permitRequirements(network string) PaymentRequirements {
+	requirements := testRequirements()
+	requirements.Network = network
+	requirements.Asset = "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+	requirements.Extra = map[string]interface{}{
+		"authType": "permit",
+		"spender":  "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913",
+		"name":     "Tok",
+		"version":  "1",
+	}
+	return requirements
+}
+
+func TestPermitPayment(t *testing.T) {
+	// The node reports a permit nonce of 5 for the owner
+	srv := rpcServer(`"0x0000000000000000000000000000000000000000000000000000000000000005"`)
+	defer srv.Close()
+	registerTestNetwork(t, "permit-net", NetworkConfig{ChainID: 9, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+
+	requirements := permitRequirements("permit-net")
+	if err := requirements.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	c := NewClient("permit-net", "").WithPrivateKey(testKey)
+	header, err := c.createPaymentHeader(context.Background(), requirements)
+	if err != nil {
+		t.Fatalf("createPaymentHeader: %v", err)
+	}
+	permit := header.Payload.Permit
+	if permit == nil || header.Payload.Authorization != nil {
+		t.Fatalf("payload = %+v, want only a permit", header.Payload)
+	}
+	if permit.Nonce != "5" || permit.Value != "1000" {
+		t.Fatalf("permit nonce %s value %s, want 5 and 1000", permit.Nonce, permit.Value)
+	}
+
+	domain, err := authorizationDomain(requirements)
+	if err != nil {
+		t.Fatalf("authorizationDomain: %v", err)
+	}
+	digest, err := HashTypedData(domain, permitTypedData(permit))
+	if err != nil {
+		t.Fatalf("HashTypedData: %v", err)
+	}
+	if got := recoverSigner(t, digest, permit.V, permit.R, permit.S); got != permit.Owner {
+		t.Fatalf("permit signature recovers to %s, want owner %s", got, permit.Owner)
+	}
+
+	encoded, err := EncodePaymentHeader(*header)
+	if err != nil {
+		t.Fatalf("EncodePaymentHeader: %v", err)
+	}
+	if _, err := ParsePaymentHeader(encoded); err != nil {
+		t.Fatalf("ParsePaymentHeader: %v", err)
+	}
+}
+
+func TestPermitUnsupportedToken(t *testing.T) {
+	// A token without nonces() returns empty data
+	srv := rpcServer(`"0x"`)
+	defer srv.Close()
+	registerTestNetwork(t, "permit-net", NetworkConfig{ChainID: 9, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+
+	c := NewClient("permit-net", "").WithPrivateKey(testKey)
+	if _, err := c.createPaymentHeader(context.Background(), permitRequirements("permit-net")); !errors.Is(err, ErrUnsupportedToken) {
+		t.Fatalf("err = %v, want ErrUnsupportedToken", err)
+	}
+}
+
+func TestPermitRequiresSpender(t *testing.T) {
+	requirements := permitRequirements("base-sepolia")
+	delete(requirements.Extra, "spender")
+	if err := requirements.Validate(); !errors.Is(err, ErrInvalidRequirements) {
+		t.Fatalf("err = %v, want ErrInvalidRequirements", err)
+	}
+}
@@ -0,0 +1,77 @@
+package nova402
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by a client's paid requests and
+// facilitator calls. The rate and burst are read from the client on each
+// wait, so the zero value is ready to use.
+type rateLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token, refilling at rps up to burst, and returns how long
+// the caller must wait before using it. When the wait would outlast deadline
+// no token is taken and ok is false.
+func (l *rateLimiter) reserve(rps float64, burst int, now, deadline time.Time) (wait time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.last.IsZero() {
+		l.tokens = float64(burst)
+	} else if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens = math.Min(float64(burst), l.tokens+elapsed*rps)
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		wait = time.Duration((1 - l.tokens) / rps * float64(time.Second))
+	}
+	if !deadline.IsZero() && now.Add(wait).After(deadline) {
+		return wait, false
+	}
+	l.tokens--
+	return wait, true
+}
+
+// cancel returns a reserved token that was not used
+func (l *rateLimiter) cancel(burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = math.Min(float64(burst), l.tokens+1)
+}
+
+// waitRateLimit blocks until RateLimit allows another outbound request. It
+// fails with ErrRateLimited without waiting when ctx's deadline would pass
+// first, and with ctx's error when ctx is done while waiting. It returns
+// immediately when no rate limit is configured.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	if c.RateLimit <= 0 {
+		return nil
+	}
+	burst := c.RateBurst
+	if burst < 1 {
+		burst = 1
+	}
+
+	deadline, _ := ctx.Deadline()
+	wait, ok := c.limiter.reserve(c.RateLimit, burst, time.Now(), deadline)
+	if !ok {
+		return fmt.Errorf("%w: waiting %s would exceed the context deadline", ErrRateLimited, wait.Round(time.Millisecond))
+	}
+	if wait <= 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, wait); err != nil {
+		c.limiter.cancel(burst)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	c, err := NewClientWithOptionsE(WithNetwork("base-sepolia"), WithRateLimit(20, 2))
+	if err != nil {
+		t.Fatalf("NewClientWithOptionsE: %v", err)
+	}
+
+	// A burst of 2 passes at once; the next 2 wait 50ms each at 20/s
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := c.waitRateLimit(context.Background()); err != nil {
+			t.Fatalf("waitRateLimit %d: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Fatalf("4 requests took %v, want about 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.waitRateLimit(ctx); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("wait past the deadline: err = %v, want ErrRateLimited", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.waitRateLimit(ctx); err != nil {
+		t.Fatalf("waitRateLimit after refill: %v", err)
+	}
+}
+
+func TestRateLimitRejectsInvalid(t *testing.T) {
+	if _, err := NewClientWithOptionsE(WithNetwork("base-sepolia"), WithRateLimit(-1, 0)); err == nil {
+		t.Fatal("negative rate accepted")
+	}
+}
@@ -0,0 +1,70 @@
+package nova402
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// maxRedirects matches the redirect limit of http.Client's default policy
+const maxRedirects = 10
+
+// RedirectPolicy controls how a paid request follows redirects. net/http
+// copies custom headers such as X-PAYMENT to every redirect target, so
+// following a cross-origin redirect would hand the signed payment to another
+// host.
+type RedirectPolicy string
+
+const (
+	// RedirectSameOrigin follows redirects to the same scheme and host,
+	// replaying X-PAYMENT, and fails cross-origin redirects with
+	// ErrCrossOriginRedirect. It is the default.
+	RedirectSameOrigin RedirectPolicy = "same-origin"
+	// RedirectStripPayment follows every redirect but sends X-PAYMENT only
+	// to the original origin
+	RedirectStripPayment RedirectPolicy = "strip-payment"
+	// RedirectNone does not follow redirects; the redirect response is
+	// returned to the caller
+	RedirectNone RedirectPolicy = "none"
+)
+
+// paidHTTPClient returns a copy of the HTTP client that applies the
+// RedirectPolicy to paid requests before any CheckRedirect of its own
+func (c *Client) paidHTTPClient() *http.Client {
+	client := *c.httpClient()
+	next := client.CheckRedirect
+	policy := c.RedirectPolicy
+
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		original := via[0]
+		sameOrigin := req.URL.Scheme == original.URL.Scheme && req.URL.Host == original.URL.Host
+		payment := original.Header.Get("X-PAYMENT")
+
+		switch policy {
+		case RedirectNone:
+			return http.ErrUseLastResponse
+		case RedirectStripPayment:
+			if !sameOrigin {
+				req.Header.Del("X-PAYMENT")
+			} else if payment != "" {
+				req.Header.Set("X-PAYMENT", payment)
+			}
+		default:
+			if !sameOrigin {
+				return fmt.Errorf("%w: %s redirected to %s", ErrCrossOriginRedirect, original.URL.Host, req.URL.Host)
+			}
+			if payment != "" {
+				req.Header.Set("X-PAYMENT", payment)
+			}
+		}
+
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &client
+}
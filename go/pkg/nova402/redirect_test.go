@@ -0,0 +1,92 @@
+package nova402
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// redirectServers returns a paid server redirecting /same to its own /final
+// and /cross to other, and a count of the X-PAYMENT headers other received
+func redirectServers(t *testing.T) (srv *httptest.Server, leaks *atomic.Int32) {
+	leaks = new(atomic.Int32)
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") != "" {
+			leaks.Add(1)
+		}
+		w.Write([]byte("other"))
+	}))
+	t.Cleanup(other.Close)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/cross":
+			http.Redirect(w, r, other.URL+"/x", http.StatusFound)
+		case "/final":
+			w.Write([]byte("final"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, leaks
+}
+
+func TestSameOriginRedirectKeepsPayment(t *testing.T) {
+	srv, _ := redirectServers(t)
+	c := NewClient("base-sepolia", "", WithRetries(2, time.Nanosecond)).WithPrivateKey(testKey)
+	resp, err := c.Get(srv.URL+"/same", nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "final" {
+		t.Fatalf("body = %q, want the redirect target's \"final\"", body)
+	}
+}
+
+func TestCrossOriginRedirect(t *testing.T) {
+	srv, leaks := redirectServers(t)
+	c := NewClient("base-sepolia", "", WithRetries(2, time.Nanosecond)).WithPrivateKey(testKey)
+	if _, err := c.Get(srv.URL+"/cross", nil); !errors.Is(err, ErrCrossOriginRedirect) {
+		t.Fatalf("err = %v, want ErrCrossOriginRedirect", err)
+	}
+
+	c.RedirectPolicy = RedirectStripPayment
+	resp, err := c.Get(srv.URL+"/cross", nil)
+	if err != nil {
+		t.Fatalf("Get with RedirectStripPayment: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "other" {
+		t.Fatalf("body = %q, want the other origin's \"other\"", body)
+	}
+	if n := leaks.Load(); n != 0 {
+		t.Fatalf("other origin received %d X-PAYMENT headers, want 0", n)
+	}
+}
+
+func TestRedirectNone(t *testing.T) {
+	srv, _ := redirectServers(t)
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	c.RedirectPolicy = RedirectNone
+	resp, err := c.Get(srv.URL+"/same", nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("status = %d, want the unfollowed 302", resp.StatusCode)
+	}
+}
@@ -0,0 +1,274 @@
+package nova402
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultUSDCDecimals is assumed for USDC registered without explicit decimals
+const defaultUSDCDecimals = 6
+
+// registry guards concurrent access to network configuration
+type registry struct {
+	mu       sync.RWMutex
+	networks map[string]NetworkConfig
+}
+
+// networkRegistry is seeded with a copy of the built-in Networks table, so
+// later writes to Networks cannot race with lookups
+var networkRegistry = &registry{
+	networks: copyNetworks(Networks),
+}
+
+// copyNetworks returns a copy of networks that shares no RPCUrls slices with it
+func copyNetworks(networks map[string]NetworkConfig) map[string]NetworkConfig {
+	copied := make(map[string]NetworkConfig, len(networks))
+	for name, config := range networks {
+		config.RPCUrls = append([]string(nil), config.RPCUrls...)
+		copied[name] = config
+	}
+	return copied
+}
+
+// NetworkConfigs returns a snapshot of every registered network by name.
+// Changing it does not affect the registry; use RegisterNetwork for that.
+func NetworkConfigs() map[string]NetworkConfig {
+	networkRegistry.mu.RLock()
+	defer networkRegistry.mu.RUnlock()
+	return copyNetworks(networkRegistry.networks)
+}
+
+func (r *registry) network(name string) (NetworkConfig, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	config, exists := r.networks[name]
+	return config, exists
+}
+
+// networkNames returns the sorted names of networks matching the filter, or
+// of all networks when filter is nil
+func (r *registry) networkNames(filter func(NetworkConfig) bool) []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.networks))
+	for name, config := range r.networks {
+		if filter == nil || filter(config) {
+			names = append(names, name)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+func (r *registry) registerNetwork(name string, cfg NetworkConfig, overwrite bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.networks[name]; exists && !overwrite {
+		return fmt.Errorf("%w: %s", ErrNetworkExists, name)
+	}
+	r.networks[name] = cfg
+	return nil
+}
+
+// NetworkAliases maps common alternative names, in lower case, onto
+// registered network names. ResolveNetwork and GetNetworkConfig consult it.
+// Add entries before using the package concurrently.
+var NetworkAliases = map[string]string{
+	"base":                "base-mainnet",
+	"base-main":           "base-mainnet",
+	"base-testnet":        "base-sepolia",
+	"solana":              "solana-mainnet",
+	"solana-mainnet-beta": "solana-mainnet",
+	"solana-testnet":      "solana-devnet",
+	"polygon-mainnet":     "polygon",
+	"matic":               "polygon",
+	"bsc-mainnet":         "bsc",
+	"bnb":                 "bsc",
+	"sei-mainnet":         "sei",
+	"peaq-mainnet":        "peaq",
+}
+
+// ResolveNetwork returns the registered name for a network given by its
+// registered name or an alias from NetworkAliases, ignoring case
+func ResolveNetwork(name string) (string, error) {
+	if _, exists := networkRegistry.network(name); exists {
+		return name, nil
+	}
+	key := strings.ToLower(strings.TrimSpace(name))
+	if _, exists := networkRegistry.network(key); exists {
+		return key, nil
+	}
+	if canonical, exists := NetworkAliases[key]; exists {
+		if _, exists := networkRegistry.network(canonical); exists {
+			return canonical, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedNetwork, name)
+}
+
+// canonicalNetwork resolves name like ResolveNetwork, returning it unchanged
+// when it is not known
+func canonicalNetwork(name string) string {
+	if resolved, err := ResolveNetwork(name); err == nil {
+		return resolved
+	}
+	return name
+}
+
+// RegisterNetwork adds a network configuration at runtime. It fails with
+// ErrNetworkExists if the name is already registered, unless overwrite is set.
+func RegisterNetwork(name string, cfg NetworkConfig, overwrite bool) error {
+	if name == "" {
+		return fmt.Errorf("network name is required")
+	}
+	if cfg.Type != NetworkTypeEVM && cfg.Type != NetworkTypeSolana {
+		return fmt.Errorf("%w: unknown network type %q", ErrUnsupportedNetwork, cfg.Type)
+	}
+	return networkRegistry.registerNetwork(name, cfg, overwrite)
+}
+
+// RegisterRPCEndpoints sets the fallback RPC endpoints of a registered
+// network, replacing any set before. Balance, gas, nonce, confirmation and
+// settlement calls try the network's RPCUrl and then urls in order, starting
+// from whichever endpoint last answered.
+func RegisterRPCEndpoints(network string, urls []string) error {
+	for _, endpoint := range urls {
+		parsed, err := url.Parse(endpoint)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid RPC endpoint for %s: %q", network, endpoint)
+		}
+	}
+	return networkRegistry.setRPCEndpoints(network, append([]string(nil), urls...))
+}
+
+func (r *registry) setRPCEndpoints(network string, urls []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config, exists := r.networks[network]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnsupportedNetwork, network)
+	}
+	config.RPCUrls = urls
+	r.networks[network] = config
+	return nil
+}
+
+// RegisterUSDC sets the USDC contract or mint address for a registered
+// network in DefaultTokens. It fails with ErrTokenExists if an address is
+// already set, unless overwrite is set. Decimals default to 6 when not
+// already configured.
+func RegisterUSDC(network, address string, overwrite bool) error {
+	config, exists := networkRegistry.network(network)
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnsupportedNetwork, network)
+	}
+	if !isValidAddress(address, config.Type) {
+		return fmt.Errorf("invalid USDC address for %s: %s", network, address)
+	}
+
+	decimals := defaultUSDCDecimals
+	if existing, exists := DefaultTokens.Lookup(network, USDCSymbol); exists {
+		decimals = existing.Decimals
+	}
+	return DefaultTokens.Register(network, Token{Symbol: USDCSymbol, Address: address, Decimals: decimals}, overwrite)
+}
+
+// ListNetworks returns the names of all registered networks, sorted
+func ListNetworks() []string {
+	return networkRegistry.networkNames(nil)
+}
+
+// ListEVMNetworks returns the names of registered EVM networks, sorted
+func ListEVMNetworks() []string {
+	return networkRegistry.networkNames(func(config NetworkConfig) bool {
+		return config.Type == NetworkTypeEVM
+	})
+}
+
+// ListSolanaNetworks returns the names of registered Solana networks, sorted
+func ListSolanaNetworks() []string {
+	return networkRegistry.networkNames(func(config NetworkConfig) bool {
+		return config.Type == NetworkTypeSolana
+	})
+}
+
+// NetworkByChainID finds the registered network with the given chain ID. EVM
+// chain IDs may be given as any integer type, a JSON number, a decimal string
+// or a CAIP-2 "eip155:<id>" string; Solana chain IDs are cluster names such as
+// "mainnet" or "devnet". It fails when no network or more than one matches.
+func NetworkByChainID(id interface{}) (string, *NetworkConfig, error) {
+	want, ok := chainIDKey(id)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: invalid chain ID %v", ErrUnsupportedNetwork, id)
+	}
+
+	names := networkRegistry.networkNames(func(config NetworkConfig) bool {
+		key, ok := chainIDKey(config.ChainID)
+		return ok && key == want
+	})
+	switch len(names) {
+	case 0:
+		return "", nil, fmt.Errorf("%w: no network with chain ID %v", ErrUnsupportedNetwork, id)
+	case 1:
+	default:
+		return "", nil, fmt.Errorf("chain ID %v matches multiple networks: %s", id, strings.Join(names, ", "))
+	}
+
+	config, exists := networkRegistry.network(names[0])
+	if !exists {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, names[0])
+	}
+	return names[0], &config, nil
+}
+
+// chainIDKey reduces the int-or-string chain ID representations to one
+// comparable form: the decimal ID for EVM chains, the lowercased cluster name
+// for Solana
+func chainIDKey(id interface{}) (string, bool) {
+	switch v := id.(type) {
+	case int:
+		return strconv.FormatInt(int64(v), 10), true
+	case int32:
+		return strconv.FormatInt(int64(v), 10), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		if v != math.Trunc(v) || v < 0 {
+			return "", false
+		}
+		return strconv.FormatFloat(v, 'f', 0, 64), true
+	case json.Number:
+		return chainIDKey(string(v))
+	case string:
+		v = strings.ToLower(strings.TrimSpace(v))
+		v = strings.TrimPrefix(v, "eip155:")
+		if v == "" {
+			return "", false
+		}
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			return strconv.FormatUint(n, 10), true
+		}
+		return v, true
+	}
+	return "", false
+}
+
+// ListSchemes returns a copy of SupportedSchemes
+func ListSchemes() []string {
+	return append([]string(nil), SupportedSchemes...)
+}
@@ -0,0 +1,165 @@
+package nova402
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestRegisterNetwork(t *testing.T) {
+	cleanupNetwork(t, "arbitrum-test")
+	cfg := NetworkConfig{ChainID: 42161, Name: "Arbitrum", Type: NetworkTypeEVM, RPCUrl: "https://arb1.arbitrum.io/rpc"}
+	if err := RegisterNetwork("arbitrum-test", cfg, false); err != nil {
+		t.Fatalf("RegisterNetwork: %v", err)
+	}
+	if err := RegisterNetwork("arbitrum-test", cfg, false); !errors.Is(err, ErrNetworkExists) {
+		t.Fatalf("second RegisterNetwork: err = %v, want ErrNetworkExists", err)
+	}
+	cfg.Name = "Arbitrum One"
+	if err := RegisterNetwork("arbitrum-test", cfg, true); err != nil {
+		t.Fatalf("RegisterNetwork with overwrite: %v", err)
+	}
+	if got, err := GetNetworkConfig("arbitrum-test"); err != nil || got.Name != "Arbitrum One" {
+		t.Fatalf("GetNetworkConfig = %+v, %v; want the overwritten config", got, err)
+	}
+
+	if err := RegisterUSDC("arbitrum-test", "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", false); err != nil {
+		t.Fatalf("RegisterUSDC: %v", err)
+	}
+	if !IsEVMNetwork("arbitrum-test") {
+		t.Fatal("registered EVM network not reported as EVM")
+	}
+	if _, _, chainID, _, err := BuildEIP712Domain("arbitrum-test"); err != nil || chainID != 42161 {
+		t.Fatalf("BuildEIP712Domain chain ID = %d, %v; want 42161", chainID, err)
+	}
+}
+
+func TestRegisterUSDCRequiresNetwork(t *testing.T) {
+	err := RegisterUSDC("not-a-network", "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", false)
+	if !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("err = %v, want ErrUnsupportedNetwork", err)
+	}
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	const writers = 8
+	for i := 0; i < writers; i++ {
+		cleanupNetwork(t, fmt.Sprintf("race-%d", i))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("race-%d", i)
+			if err := RegisterNetwork(name, NetworkConfig{ChainID: 900000 + i, Type: NetworkTypeEVM}, false); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := RegisterUSDC(name, "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", false); err != nil {
+				t.Error(err)
+			}
+			if err := RegisterRPCEndpoints(name, []string{"https://rpc.example"}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				IsEVMNetwork("base-mainnet")
+				GetNetworkConfig("race-1")
+				GetUSDCAddress("race-1")
+				GetUSDCDecimals("race-2")
+				ListNetworks()
+				NetworkConfigs()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < writers; i++ {
+		if _, err := GetUSDCAddress(fmt.Sprintf("race-%d", i)); err != nil {
+			t.Fatalf("race-%d: %v", i, err)
+		}
+	}
+}
+
+func TestNetworkConfigsIsSnapshot(t *testing.T) {
+	snapshot := NetworkConfigs()
+	config := snapshot["base-sepolia"]
+	config.ChainID = 1
+	config.RPCUrls = append(config.RPCUrls, "https://changed.example")
+	snapshot["base-sepolia"] = config
+	delete(snapshot, "base-mainnet")
+
+	got, err := GetNetworkConfig("base-sepolia")
+	if err != nil || got.ChainID == 1 {
+		t.Fatalf("changing the snapshot changed the registry: %+v, %v", got, err)
+	}
+	if _, err := GetNetworkConfig("base-mainnet"); err != nil {
+		t.Fatalf("deleting from the snapshot changed the registry: %v", err)
+	}
+}
+
+func TestListNetworksSorted(t *testing.T) {
+	for name, list := range map[string][]string{
+		"ListNetworks":       ListNetworks(),
+		"ListEVMNetworks":    ListEVMNetworks(),
+		"ListSolanaNetworks": ListSolanaNetworks(),
+	} {
+		if len(list) == 0 || !sort.StringsAreSorted(list) {
+			t.Fatalf("%s = %v, want a non-empty sorted list", name, list)
+		}
+	}
+	for _, name := range ListEVMNetworks() {
+		if !IsEVMNetwork(name) {
+			t.Fatalf("ListEVMNetworks includes non-EVM network %s", name)
+		}
+	}
+	for _, name := range ListSolanaNetworks() {
+		if !IsSolanaNetwork(name) {
+			t.Fatalf("ListSolanaNetworks includes non-Solana network %s", name)
+		}
+	}
+	if total := len(ListEVMNetworks()) + len(ListSolanaNetworks()); total != len(ListNetworks()) {
+		t.Fatalf("EVM and Solana lists hold %d networks, ListNetworks %d", total, len(ListNetworks()))
+	}
+}
+
+func TestListSchemesIsCopy(t *testing.T) {
+	schemes := ListSchemes()
+	if len(schemes) != len(SupportedSchemes) {
+		t.Fatalf("ListSchemes = %v, want %v", schemes, SupportedSchemes)
+	}
+	original := SupportedSchemes[0]
+	schemes[0] = "changed"
+	if SupportedSchemes[0] != original {
+		t.Fatal("changing the ListSchemes result changed SupportedSchemes")
+	}
+}
+
+func TestNetworkByChainID(t *testing.T) {
+	for _, id := range []interface{}{8453, int64(8453), float64(8453), json.Number("8453"), "8453", "eip155:8453"} {
+		name, config, err := NetworkByChainID(id)
+		if err != nil || name != "base-mainnet" || config.ChainID != 8453 {
+			t.Fatalf("NetworkByChainID(%#v) = %q, %v; want base-mainnet", id, name, err)
+		}
+	}
+	if name, _, err := NetworkByChainID("devnet"); err != nil || name != "solana-devnet" {
+		t.Fatalf("NetworkByChainID(\"devnet\") = %q, %v; want solana-devnet", name, err)
+	}
+	if _, _, err := NetworkByChainID(99999); err == nil {
+		t.Fatal("unknown chain ID resolved to a network")
+	}
+}
+
+func TestNetworkByChainIDAmbiguous(t *testing.T) {
+	registerTestNetwork(t, "dup-base", NetworkConfig{ChainID: 8453, Type: NetworkTypeEVM})
+	if _, _, err := NetworkByChainID(8453); err == nil {
+		t.Fatal("chain ID shared by two networks resolved to one of them")
+	}
+}
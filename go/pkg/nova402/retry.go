@@ -0,0 +1,133 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRetryBackoff is the base delay used when RetryBackoff is unset
+const DefaultRetryBackoff = 500 * time.Millisecond
+
+// retryableStatusError marks a response whose status code is worth retrying
+type retryableStatusError struct {
+	StatusCode int
+}
+
+func (e *retryableStatusError) Error() string {
+	return fmt.Sprintf("retryable status %d", e.StatusCode)
+}
+
+// retryableStatus reports whether a status code indicates a transient failure.
+// Only 429 Too Many Requests and the 500, 502, 503 and 504 server errors are
+// retried. Validation failures such as 400 Bad Request or 402 Payment Required
+// mean the payment itself was rejected, so repeating it cannot help.
+func retryableStatus(code int) bool {
+	switch code {
+	case 429, 500, 502, 503, 504:
+		return true
+	}
+	return false
+}
+
+// isRetryable reports whether err is a transient network or server failure
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrCrossOriginRedirect) {
+		return false
+	}
+
+	var paymentErr *PaymentError
+	if errors.As(err, &paymentErr) {
+		return retryableStatus(paymentErr.StatusCode)
+	}
+
+	var statusErr *retryableStatusError
+	if errors.As(err, &statusErr) {
+		return true
+	}
+
+	// Transport failures from http.Client.Do surface as *url.Error
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// withRetry runs fn until it succeeds, fails with a non-retryable error, or
+// MaxRetries additional attempts have been made. Backoff waits are cut short
+// when ctx is done.
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= c.MaxRetries || !isRetryable(err) {
+			return err
+		}
+
+		delay := c.backoff(attempt)
+		c.logger().DebugContext(ctx, "x402: retrying",
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay),
+			slog.String("error", err.Error()))
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepContext waits for delay, returning ctx.Err() if ctx is done first
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given either as a number of
+// seconds or as an HTTP-date. A date in the past yields a zero delay.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+// backoff returns the exponential delay before the given retry attempt,
+// with jitter spread over the upper half of the interval
+func (c *Client) backoff(attempt int) time.Duration {
+	base := c.RetryBackoff
+	if base <= 0 {
+		base = DefaultRetryBackoff
+	}
+
+	delay := base << uint(attempt)
+	if delay <= 0 {
+		delay = base
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,143 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingFacilitator fails the first failures calls with status and then
+// reports the payment as valid
+func failingFacilitator(status int, failures int32, hits *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"isValid":true}`))
+	}))
+}
+
+func TestVerifyRetriesTransientStatus(t *testing.T) {
+	for _, status := range []int{429, 500, 502, 503, 504} {
+		var hits atomic.Int32
+		fac := failingFacilitator(status, 2, &hits)
+		c := NewClient("base-sepolia", fac.URL, WithRetries(2, time.Millisecond))
+		_, err := c.Verify(PaymentHeader{}, PaymentRequirements{})
+		fac.Close()
+		if err != nil {
+			t.Fatalf("status %d: Verify: %v", status, err)
+		}
+		if n := hits.Load(); n != 3 {
+			t.Fatalf("status %d: facilitator saw %d requests, want 3", status, n)
+		}
+	}
+}
+
+func TestVerifyDoesNotRetryRejection(t *testing.T) {
+	for _, status := range []int{400, 402, 404} {
+		var hits atomic.Int32
+		fac := failingFacilitator(status, 1, &hits)
+		c := NewClient("base-sepolia", fac.URL, WithRetries(2, time.Millisecond))
+		_, err := c.Verify(PaymentHeader{}, PaymentRequirements{})
+		fac.Close()
+		if err == nil {
+			t.Fatalf("status %d: Verify succeeded, want error", status)
+		}
+		if n := hits.Load(); n != 1 {
+			t.Fatalf("status %d: facilitator saw %d requests, want 1", status, n)
+		}
+	}
+}
+
+func TestRetriesGiveUpAfterMaxRetries(t *testing.T) {
+	var hits atomic.Int32
+	fac := failingFacilitator(http.StatusServiceUnavailable, 10, &hits)
+	defer fac.Close()
+	c := NewClient("base-sepolia", fac.URL, WithRetries(2, time.Millisecond))
+	if _, err := c.Verify(PaymentHeader{}, PaymentRequirements{}); err == nil {
+		t.Fatal("Verify succeeded, want error")
+	}
+	if n := hits.Load(); n != 3 {
+		t.Fatalf("facilitator saw %d requests, want 3", n)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Duration{
+		"5":                             5 * time.Second,
+		" 0 ":                           0,
+		"Thu, 01 Jan 2026 00:00:05 GMT": 5 * time.Second,
+		"Wed, 31 Dec 2025 23:59:00 GMT": 0,
+	} {
+		if got, ok := parseRetryAfter(value, now); !ok || got != want {
+			t.Fatalf("parseRetryAfter(%q) = %v, %v; want %v", value, got, ok, want)
+		}
+	}
+	for _, value := range []string{"", "-1", "soon"} {
+		if _, ok := parseRetryAfter(value, now); ok {
+			t.Fatalf("parseRetryAfter(%q) accepted", value)
+		}
+	}
+}
+
+func TestRetryAfterOn402(t *testing.T) {
+	// The HTTP date is built when the server answers, so time spent on the
+	// earlier cases does not eat into its delay
+	for _, retryAfterAt := range []func() string{
+		func() string { return "1" },
+		func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) },
+	} {
+		retryAfter := retryAfterAt()
+		var unpaid atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-PAYMENT") != "" {
+				w.Write([]byte("ok"))
+				return
+			}
+			if unpaid.Add(1) == 1 {
+				w.Header().Set("Retry-After", retryAfter)
+				w.WriteHeader(http.StatusPaymentRequired)
+				return
+			}
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+		}))
+
+		c := NewClient("base-sepolia", "", WithRetries(1, 0)).WithPrivateKey(testKey)
+		start := time.Now()
+		paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+		elapsed := time.Since(start)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("Retry-After %q: GetPaid: %v", retryAfter, err)
+		}
+		paid.Response.Body.Close()
+		if paid.Response.StatusCode != http.StatusOK || unpaid.Load() != 2 {
+			t.Fatalf("Retry-After %q: status %d after %d unpaid requests, want 200 after 2", retryAfter, paid.Response.StatusCode, unpaid.Load())
+		}
+		if elapsed < 500*time.Millisecond {
+			t.Fatalf("Retry-After %q: retried after %v, want the server's delay honored", retryAfter, elapsed)
+		}
+	}
+}
+
+func TestRetryAfterCancelledByContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusPaymentRequired)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	c := NewClient("base-sepolia", "", WithRetries(3, 0)).WithPrivateKey(testKey)
+	if _, err := c.GetPaid(ctx, srv.URL, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,255 @@
+package nova402
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rpcEndpointTimeout bounds each attempt when a network has fallback RPC
+// endpoints, so a hung endpoint does not use up the whole call
+const rpcEndpointTimeout = 5 * time.Second
+
+// broadcastMethods submit transactions. Sending one to the next endpoint after
+// the last may already have received it would broadcast it twice, so these
+// only fail over when the request never left the client.
+var broadcastMethods = map[string]bool{
+	"eth_sendRawTransaction": true,
+	"sendTransaction":        true,
+}
+
+// rpcRequest is a JSON-RPC 2.0 request envelope
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response envelope
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	} `json:"error"`
+}
+
+type rpcOverrideContextKey struct{}
+
+// withRPCOverride returns a context that sends every JSON-RPC call to
+// endpoint instead of the network's configured endpoints
+func withRPCOverride(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, rpcOverrideContextKey{}, endpoint)
+}
+
+// rpcOverrideFromContext returns the endpoint set by withRPCOverride, if any
+func rpcOverrideFromContext(ctx context.Context) string {
+	endpoint, _ := ctx.Value(rpcOverrideContextKey{}).(string)
+	return endpoint
+}
+
+// RPCError is a JSON-RPC error object returned by a node. Calls that reach
+// the node and fail there return it, so callers can tell a rejected call
+// from an unreachable endpoint with errors.As.
+type RPCError struct {
+	Code    int
+	Message string
+	// Data is the error's optional data member, such as revert data
+	Data json.RawMessage
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// alreadyKnown reports whether a broadcast was refused because the node
+// already has the transaction, meaning an earlier broadcast reached it
+func (e *RPCError) alreadyKnown() bool {
+	message := strings.ToLower(e.Message)
+	return strings.Contains(message, "already known") || strings.Contains(message, "known transaction")
+}
+
+// reverted reports whether the error is a call that reverted, which nodes
+// signal with code 3 or an "execution reverted" message
+func (e *RPCError) reverted() bool {
+	return e.Code == 3 || strings.Contains(strings.ToLower(e.Message), "revert")
+}
+
+// rpcEndpoints returns the network's RPC endpoints in failover order: RPCUrl
+// first, then RPCUrls, skipping blanks and duplicates
+func (n *NetworkConfig) rpcEndpoints() []string {
+	endpoints := make([]string, 0, 1+len(n.RPCUrls))
+	seen := make(map[string]bool, 1+len(n.RPCUrls))
+	for _, endpoint := range append([]string{n.RPCUrl}, n.RPCUrls...) {
+		if endpoint == "" || seen[endpoint] {
+			continue
+		}
+		seen[endpoint] = true
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// rpcEndpointTracker remembers, per network, the endpoint that last answered
+// so the next call starts there instead of at a dead primary
+type rpcEndpointTracker struct {
+	mu        sync.RWMutex
+	preferred map[string]string
+}
+
+func (t *rpcEndpointTracker) get(key string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.preferred[key]
+}
+
+func (t *rpcEndpointTracker) set(key, endpoint string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.preferred == nil {
+		t.preferred = make(map[string]string)
+	}
+	t.preferred[key] = endpoint
+}
+
+// callRPC performs a JSON-RPC call against the network's endpoints and decodes
+// the result into out. Endpoints that cannot be reached, answer with a non-200
+// status or send an unparseable response are skipped in favour of the next;
+// a JSON-RPC error is returned as is, since another node would give the same
+// answer. Broadcasts are only retried elsewhere when they could not have been
+// sent; see broadcastMethods. An endpoint set on ctx by SettleOptions
+// replaces them all.
+func (c *Client) callRPC(ctx context.Context, config *NetworkConfig, method string, params []interface{}, out interface{}) error {
+	if endpoint := rpcOverrideFromContext(ctx); endpoint != "" {
+		_, err := c.callEndpoint(ctx, endpoint, method, params, out)
+		return err
+	}
+
+	endpoints := config.rpcEndpoints()
+	if len(endpoints) == 0 {
+		return fmt.Errorf("network %s has no RPC endpoint", config.Name)
+	}
+
+	key := endpoints[0]
+	if preferred := c.rpcTracker.get(key); preferred != "" {
+		for i, endpoint := range endpoints {
+			if endpoint == preferred {
+				endpoints = append(append([]string{preferred}, endpoints[:i]...), endpoints[i+1:]...)
+				break
+			}
+		}
+	}
+
+	var errs []error
+	for _, endpoint := range endpoints {
+		if len(endpoints) == 1 {
+			_, err := c.callEndpoint(ctx, endpoint, method, params, out)
+			return err
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, rpcEndpointTimeout)
+		failover, err := c.callEndpoint(attemptCtx, endpoint, method, params, out)
+		cancel()
+		if err == nil || !failover {
+			c.rpcTracker.set(key, endpoint)
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if broadcastMethods[method] && !requestUnsent(err) {
+			return fmt.Errorf("%s: %w", endpoint, err)
+		}
+		c.logger().DebugContext(ctx, "x402: rpc endpoint failed",
+			slog.String("endpoint", endpoint),
+			slog.String("method", method),
+			slog.String("error", err.Error()))
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+	}
+	return fmt.Errorf("all %d rpc endpoints failed: %w", len(errs), errors.Join(errs...))
+}
+
+// requestUnsent reports whether err shows a request never reached the
+// endpoint, because no connection could be made
+func requestUnsent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// EVMCall sends a JSON-RPC request for method with params to an EVM
+// network's RPC endpoints, with the same failover as the client's own calls,
+// and returns the raw result. It is an escape hatch for queries the client
+// has no helper for, such as eth_getBlockByNumber. An error object in the
+// response is returned as an *RPCError.
+func (c *Client) EVMCall(ctx context.Context, network, method string, params ...interface{}) (json.RawMessage, error) {
+	if !IsEVMNetwork(network) {
+		return nil, fmt.Errorf("%w: EVMCall requires an EVM network, got %s", ErrUnsupportedNetwork, network)
+	}
+	return c.rpcCall(ctx, network, method, params...)
+}
+
+// rpcCall calls method on network's RPC endpoints and returns the raw result
+func (c *Client) rpcCall(ctx context.Context, network, method string, params ...interface{}) (json.RawMessage, error) {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return nil, err
+	}
+	var result json.RawMessage
+	if err := c.callRPC(ctx, config, method, params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// callEndpoint performs a JSON-RPC call against a single endpoint. failover
+// reports whether the error lies with the endpoint rather than the call.
+func (c *Client) callEndpoint(ctx context.Context, rpcURL, method string, params []interface{}, out interface{}) (failover bool, err error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	reqBody, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", rpcURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return true, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return true, fmt.Errorf("rpc returned status %d", resp.StatusCode)
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return true, fmt.Errorf("failed to parse rpc response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return false, &RPCError{Code: rpcResp.Error.Code, Message: rpcResp.Error.Message, Data: rpcResp.Error.Data}
+	}
+	if out == nil || len(rpcResp.Result) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+		return false, fmt.Errorf("failed to parse rpc result: %w", err)
+	}
+	return false, nil
+}
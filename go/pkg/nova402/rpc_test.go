@@ -0,0 +1,159 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingRPC answers every request with status, or with result when status
+// is 200, and counts the requests it receives
+func countingRPC(t *testing.T, status int, result string) (*httptest.Server, *atomic.Int32) {
+	hits := new(atomic.Int32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits
+}
+
+func TestRPCFailover(t *testing.T) {
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	dead.Close()
+	failing, failingHits := countingRPC(t, http.StatusBadGateway, "")
+	live, liveHits := countingRPC(t, http.StatusOK, `"0x10"`)
+
+	registerTestNetwork(t, "fo-test", NetworkConfig{ChainID: 999, Name: "fo", Type: NetworkTypeEVM, RPCUrl: dead.URL})
+	if err := RegisterRPCEndpoints("fo-test", []string{failing.URL, live.URL}); err != nil {
+		t.Fatalf("RegisterRPCEndpoints: %v", err)
+	}
+	config, err := GetNetworkConfig("fo-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient("fo-test", "")
+	var out string
+	if err := c.callRPC(context.Background(), config, "eth_blockNumber", nil, &out); err != nil || out != "0x10" {
+		t.Fatalf("callRPC = %q, %v; want 0x10 from the live endpoint", out, err)
+	}
+	// The live endpoint answered last, so the next call starts there
+	if err := c.callRPC(context.Background(), config, "eth_blockNumber", nil, &out); err != nil {
+		t.Fatalf("second callRPC: %v", err)
+	}
+	if f, l := failingHits.Load(), liveHits.Load(); f != 1 || l != 2 {
+		t.Fatalf("failing endpoint saw %d calls and live %d, want 1 and 2", f, l)
+	}
+
+	live.Close()
+	if err := c.callRPC(context.Background(), config, "eth_blockNumber", nil, &out); err == nil {
+		t.Fatal("callRPC succeeded with every endpoint down")
+	}
+}
+
+func TestRegisterRPCEndpointsRejectsInvalid(t *testing.T) {
+	if err := RegisterRPCEndpoints("not-a-network", nil); err == nil {
+		t.Fatal("endpoints registered for an unknown network")
+	}
+	if err := RegisterRPCEndpoints("base-sepolia", []string{"ftp://x"}); err == nil {
+		t.Fatal("non-HTTP endpoint accepted")
+	}
+}
+
+func TestRPCBroadcastDoesNotFailOver(t *testing.T) {
+	first, _ := countingRPC(t, http.StatusBadGateway, "")
+	second, secondHits := countingRPC(t, http.StatusOK, `"0x10"`)
+	registerTestNetwork(t, "fo-broadcast", NetworkConfig{ChainID: 998, Name: "fob", Type: NetworkTypeEVM, RPCUrl: first.URL, RPCUrls: []string{second.URL}})
+	config, err := GetNetworkConfig("fo-broadcast")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first node received the transaction, so resending it elsewhere
+	// could broadcast it twice
+	c := NewClient("fo-broadcast", "")
+	var out string
+	if err := c.callRPC(context.Background(), config, "eth_sendRawTransaction", []interface{}{"0x00"}, &out); err == nil {
+		t.Fatal("broadcast succeeded after the first node failed")
+	}
+	if n := secondHits.Load(); n != 0 {
+		t.Fatalf("broadcast failed over to the second node %d times", n)
+	}
+
+	// Reads still fail over
+	if err := c.callRPC(context.Background(), config, "eth_blockNumber", nil, &out); err != nil || secondHits.Load() != 1 {
+		t.Fatalf("read callRPC: %v, second node hits %d; want success after one failover", err, secondHits.Load())
+	}
+}
+
+func TestRPCCallsHonorContext(t *testing.T) {
+	// The node accepts requests and never answers them
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-block }))
+	defer srv.Close()
+	defer close(block)
+	registerTestNetwork(t, "hang-test", NetworkConfig{ChainID: 77, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+	if err := RegisterUSDC("hang-test", "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", false); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient("hang-test", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.BalanceOfWithContext(ctx, "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", "hang-test"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("BalanceOfWithContext err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("BalanceOfWithContext returned after %v, want it bounded by the 100ms deadline", elapsed)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start = time.Now()
+	if _, err := c.EstimateGasWithContext(ctx, "hang-test", *signedAuthorization(t)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("EstimateGasWithContext err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("EstimateGasWithContext returned %v after cancellation, want promptly", elapsed)
+	}
+}
+
+func TestEVMCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); strings.Contains(string(body), "bad-param") {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"bad params","data":"0x01"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"number":"0x10"}}`))
+	}))
+	defer srv.Close()
+	registerTestNetwork(t, "call-test", NetworkConfig{ChainID: 78, Type: NetworkTypeEVM, RPCUrl: srv.URL})
+
+	c := NewClient("call-test", "")
+	raw, err := c.EVMCall(context.Background(), "call-test", "eth_getBlockByNumber", "latest", false)
+	if err != nil || string(raw) != `{"number":"0x10"}` {
+		t.Fatalf("EVMCall = %s, %v; want the raw block", raw, err)
+	}
+
+	_, err = c.EVMCall(context.Background(), "call-test", "eth_getBlockByNumber", "latest", false, "bad-param")
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32602 || string(rpcErr.Data) != `"0x01"` {
+		t.Fatalf("err = %v, want an RPCError with code -32602 and data", err)
+	}
+
+	if _, err := c.EVMCall(context.Background(), "solana", "x"); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("EVMCall on Solana: err = %v, want ErrUnsupportedNetwork", err)
+	}
+}
@@ -0,0 +1,113 @@
+package nova402
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RequirementSelector chooses which of a resource's accepted payment requirements to pay
+type RequirementSelector func([]PaymentRequirements) (PaymentRequirements, error)
+
+// SelectByNetwork returns a selector that picks the first requirement for the
+// given network. When network is empty the first requirement is used.
+func SelectByNetwork(network string) RequirementSelector {
+	return func(accepts []PaymentRequirements) (PaymentRequirements, error) {
+		if len(accepts) == 0 {
+			return PaymentRequirements{}, ErrNoPaymentRequirements
+		}
+		if network == "" {
+			return accepts[0], nil
+		}
+
+		available := make([]string, 0, len(accepts))
+		for _, req := range accepts {
+			if sameNetwork(req.Network, network) {
+				return req, nil
+			}
+			available = append(available, req.Network)
+		}
+		return PaymentRequirements{}, fmt.Errorf("%w: no payment requirement for network %s (available: %s)", ErrNetworkMismatch, network, strings.Join(available, ", "))
+	}
+}
+
+// sameNetwork reports whether two network names, either of which may be an
+// alias, refer to the same network
+func sameNetwork(a, b string) bool {
+	return a == b || canonicalNetwork(a) == canonicalNetwork(b)
+}
+
+// SelectCheapest picks the requirement with the lowest MaxAmountRequired
+func SelectCheapest(accepts []PaymentRequirements) (PaymentRequirements, error) {
+	var cheapest *big.Int
+	index := -1
+	for i, req := range accepts {
+		amount, ok := new(big.Int).SetString(req.MaxAmountRequired, 10)
+		if !ok {
+			continue
+		}
+		if cheapest == nil || amount.Cmp(cheapest) < 0 {
+			cheapest = amount
+			index = i
+		}
+	}
+	if index < 0 {
+		return PaymentRequirements{}, fmt.Errorf("no payment requirement with a valid amount")
+	}
+	return accepts[index], nil
+}
+
+// selectRequirement applies the client's selector, defaulting to the first
+// requirement on a network the client pays on, and checks that the chosen
+// requirement is on such a network
+func (c *Client) selectRequirement(accepts []PaymentRequirements) (PaymentRequirements, error) {
+	if c.RequirementSelector == nil && len(c.AllowedNetworks) == 0 {
+		return SelectByNetwork(c.Network)(accepts)
+	}
+
+	var selected PaymentRequirements
+	if c.RequirementSelector != nil {
+		var err error
+		if selected, err = c.RequirementSelector(accepts); err != nil {
+			return PaymentRequirements{}, err
+		}
+	} else {
+		if len(accepts) == 0 {
+			return PaymentRequirements{}, ErrNoPaymentRequirements
+		}
+		selected = accepts[0]
+		for _, req := range accepts {
+			if c.paysOn(req.Network) {
+				selected = req
+				break
+			}
+		}
+	}
+	if err := c.checkNetwork(selected.Network); err != nil {
+		return PaymentRequirements{}, err
+	}
+	return selected, nil
+}
+
+// paysOn reports whether the client will pay on network: its own Network or
+// one of AllowedNetworks. A client without a Network pays on any network.
+func (c *Client) paysOn(network string) bool {
+	if c.Network == "" || sameNetwork(network, c.Network) {
+		return true
+	}
+	for _, allowed := range c.AllowedNetworks {
+		if allowed == "*" || sameNetwork(network, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNetwork fails with ErrNetworkMismatch when the client does not pay on network
+func (c *Client) checkNetwork(network string) error {
+	if c.paysOn(network) {
+		return nil
+	}
+	configured := append([]string{c.Network}, c.AllowedNetworks...)
+	return fmt.Errorf("%w: requirement is for %s, client is configured for %s", ErrNetworkMismatch, network, strings.Join(configured, ", "))
+}
@@ -0,0 +1,35 @@
+package nova402
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelectRequirementNetworkMismatch(t *testing.T) {
+	polygon := PaymentRequirements{Network: "polygon", MaxAmountRequired: "1"}
+	sepolia := PaymentRequirements{Network: "base-sepolia", MaxAmountRequired: "5"}
+
+	c := NewClientWithOptions(WithNetwork("base"))
+	if _, err := c.selectRequirement([]PaymentRequirements{polygon}); !errors.Is(err, ErrNetworkMismatch) {
+		t.Fatalf("err = %v, want ErrNetworkMismatch", err)
+	}
+	// A selector cannot pick a network the client is not on
+	c = NewClientWithOptions(WithNetwork("base"), WithRequirementSelector(SelectCheapest))
+	if _, err := c.selectRequirement([]PaymentRequirements{sepolia, polygon}); !errors.Is(err, ErrNetworkMismatch) {
+		t.Fatalf("with SelectCheapest: err = %v, want ErrNetworkMismatch", err)
+	}
+}
+
+func TestWithAllowedNetworks(t *testing.T) {
+	polygon := PaymentRequirements{Network: "polygon", MaxAmountRequired: "1"}
+	sepolia := PaymentRequirements{Network: "base-sepolia", MaxAmountRequired: "5"}
+
+	c := NewClientWithOptions(WithNetwork("base"), WithAllowedNetworks("base-sepolia"))
+	if requirement, err := c.selectRequirement([]PaymentRequirements{polygon, sepolia}); err != nil || requirement.Network != "base-sepolia" {
+		t.Fatalf("selectRequirement = %+v, %v; want base-sepolia", requirement, err)
+	}
+	c = NewClientWithOptions(WithNetwork("base"), WithAllowedNetworks("*"), WithRequirementSelector(SelectCheapest))
+	if requirement, err := c.selectRequirement([]PaymentRequirements{sepolia, polygon}); err != nil || requirement.Network != "polygon" {
+		t.Fatalf("selectRequirement with every network allowed = %+v, %v; want the cheaper polygon", requirement, err)
+	}
+}
@@ -0,0 +1,183 @@
+package nova402
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// EIP712Domain identifies the contract and chain a typed-data signature is bound to
+type EIP712Domain struct {
+	Name              string
+	Version           string
+	ChainID           int
+	VerifyingContract string
+}
+
+// TypedDataField is a single member of an EIP-712 struct type
+type TypedDataField struct {
+	Name string
+	Type string
+}
+
+// TypedData is an EIP-712 message together with the struct types it uses
+type TypedData struct {
+	PrimaryType string
+	Types       map[string][]TypedDataField
+	Message     map[string]interface{}
+}
+
+// Signer produces EIP-712 signatures for payment authorizations. Implement it
+// to keep keys in a KMS, hardware wallet or remote signing service.
+type Signer interface {
+	// SignTypedData returns a 65-byte r || s || v signature over the EIP-712
+	// digest of message in domain
+	SignTypedData(domain EIP712Domain, message TypedData) ([]byte, error)
+	// Address returns the checksummed address signatures recover to
+	Address() (string, error)
+}
+
+// LocalSigner signs with an in-memory secp256k1 private key
+type LocalSigner struct {
+	key     *ecdsa.PrivateKey
+	address string
+}
+
+// NewLocalSigner creates a signer from a hex private key, with or without 0x prefix
+func NewLocalSigner(hexKey string) (*LocalSigner, error) {
+	key, err := parsePrivateKey(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey).Hex(),
+	}, nil
+}
+
+// Address returns the signer's checksummed address
+func (s *LocalSigner) Address() (string, error) {
+	return s.address, nil
+}
+
+// SignTypedData signs the EIP-712 digest of message, returning v as 27 or 28
+func (s *LocalSigner) SignTypedData(domain EIP712Domain, message TypedData) ([]byte, error) {
+	digest, err := HashTypedData(domain, message)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := crypto.Sign(digest, s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// HashTypedData returns the 32-byte EIP-712 digest of message in domain, for
+// signers that only expose a raw hash signing primitive
+func HashTypedData(domain EIP712Domain, message TypedData) ([]byte, error) {
+	types := apitypes.Types{
+		"EIP712Domain": {
+			{Name: "name", Type: "string"},
+			{Name: "version", Type: "string"},
+			{Name: "chainId", Type: "uint256"},
+			{Name: "verifyingContract", Type: "address"},
+		},
+	}
+	for name, fields := range message.Types {
+		for _, field := range fields {
+			types[name] = append(types[name], apitypes.Type{Name: field.Name, Type: field.Type})
+		}
+	}
+
+	typedData := apitypes.TypedData{
+		Types:       types,
+		PrimaryType: message.PrimaryType,
+		Domain: apitypes.TypedDataDomain{
+			Name:              domain.Name,
+			Version:           domain.Version,
+			ChainId:           (*math.HexOrDecimal256)(big.NewInt(int64(domain.ChainID))),
+			VerifyingContract: domain.VerifyingContract,
+		},
+		Message: message.Message,
+	}
+
+	digest, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+	return digest, nil
+}
+
+// signer returns the configured Signer, falling back to a LocalSigner built
+// from PrivateKey
+func (c *Client) signer() (Signer, error) {
+	if c.Signer != nil {
+		return c.Signer, nil
+	}
+	if c.PrivateKey == "" {
+		return nil, ErrNoPrivateKey
+	}
+	return NewLocalSigner(c.PrivateKey)
+}
+
+// SolanaSigner signs serialized Solana transaction messages with an ed25519 key
+type SolanaSigner interface {
+	// SignTransaction returns the 64-byte ed25519 signature of a serialized message
+	SignTransaction(message []byte) ([]byte, error)
+	// PublicKey returns the base58 public key of the fee payer and token owner
+	PublicKey() (string, error)
+}
+
+// LocalSolanaSigner signs with an in-memory ed25519 private key
+type LocalSolanaSigner struct {
+	key ed25519.PrivateKey
+}
+
+// NewLocalSolanaSigner creates a signer from a base58 encoded 64-byte secret
+// key, the format produced by solana-keygen and most wallets
+func NewLocalSolanaSigner(base58Secret string) (*LocalSolanaSigner, error) {
+	secret, err := base58Decode(base58Secret)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+	if len(secret) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%w: expected %d-byte solana secret key, got %d", ErrInvalidPrivateKey, ed25519.PrivateKeySize, len(secret))
+	}
+
+	key := ed25519.NewKeyFromSeed(secret[:ed25519.SeedSize])
+	if !bytes.Equal(key[ed25519.SeedSize:], secret[ed25519.SeedSize:]) {
+		return nil, fmt.Errorf("%w: solana secret key does not match its public key", ErrInvalidPrivateKey)
+	}
+	return &LocalSolanaSigner{key: key}, nil
+}
+
+// PublicKey returns the signer's base58 public key
+func (s *LocalSolanaSigner) PublicKey() (string, error) {
+	return base58Encode(s.key.Public().(ed25519.PublicKey)), nil
+}
+
+// SignTransaction signs a serialized transaction message
+func (s *LocalSolanaSigner) SignTransaction(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, message), nil
+}
+
+// solanaSigner returns the configured SolanaSigner, falling back to a
+// LocalSolanaSigner built from PrivateKey
+func (c *Client) solanaSigner() (SolanaSigner, error) {
+	if c.SolanaSigner != nil {
+		return c.SolanaSigner, nil
+	}
+	if c.PrivateKey == "" {
+		return nil, ErrNoPrivateKey
+	}
+	return NewLocalSolanaSigner(c.PrivateKey)
+}
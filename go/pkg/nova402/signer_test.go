@@ -0,0 +1,115 @@
+package nova402
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// countingSigner counts the typed data it is asked to sign
+type countingSigner struct {
+	Signer
+	signed int
+}
+
+func (s *countingSigner) SignTypedData(domain EIP712Domain, message TypedData) ([]byte, error) {
+	s.signed++
+	return s.Signer.SignTypedData(domain, message)
+}
+
+// recoverAuthorizer returns the address that signed auth on network
+func recoverAuthorizer(t *testing.T, auth *EIP3009Authorization, network string) string {
+	t.Helper()
+	name, version, chainID, verifyingContract, err := BuildEIP712Domain(network)
+	if err != nil {
+		t.Fatalf("BuildEIP712Domain: %v", err)
+	}
+	digest, err := HashTypedData(EIP712Domain{name, version, chainID, verifyingContract}, authorizationTypedData(auth, AuthTypeTransfer))
+	if err != nil {
+		t.Fatalf("HashTypedData: %v", err)
+	}
+	sig := append(hexutil.MustDecode(auth.R), hexutil.MustDecode(auth.S)...)
+	sig = append(sig, byte(auth.V-27))
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		t.Fatalf("SigToPub: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub).Hex()
+}
+
+func TestAuthorizationRecoversToPrivateKey(t *testing.T) {
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	req := testRequirements()
+	validAfter, validBefore := req.ValidityWindow(time.Now())
+	auth, err := c.buildAuthorization(context.Background(), req, validAfter, validBefore)
+	if err != nil {
+		t.Fatalf("buildAuthorization: %v", err)
+	}
+	if got := recoverAuthorizer(t, auth, "base-sepolia"); got != auth.From {
+		t.Fatalf("signature recovers to %s, want %s", got, auth.From)
+	}
+}
+
+func TestAuthorizationUsesInjectedSigner(t *testing.T) {
+	local, err := NewLocalSigner(testKey)
+	if err != nil {
+		t.Fatalf("NewLocalSigner: %v", err)
+	}
+	signer := &countingSigner{Signer: local}
+	c := NewClient("base-sepolia", "", WithSigner(signer))
+	req := testRequirements()
+	validAfter, validBefore := req.ValidityWindow(time.Now())
+	auth, err := c.buildAuthorization(context.Background(), req, validAfter, validBefore)
+	if err != nil {
+		t.Fatalf("buildAuthorization: %v", err)
+	}
+	if signer.signed != 1 {
+		t.Fatalf("signer called %d times, want 1", signer.signed)
+	}
+	want, _ := local.Address()
+	if auth.From != want || recoverAuthorizer(t, auth, "base-sepolia") != want {
+		t.Fatalf("authorization from %s, want %s", auth.From, want)
+	}
+}
+
+func TestLocalSolanaSigner(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewLocalSolanaSigner(base58Encode(priv))
+	if err != nil {
+		t.Fatalf("NewLocalSolanaSigner: %v", err)
+	}
+	if got, _ := signer.PublicKey(); got != base58Encode(pub) {
+		t.Fatalf("PublicKey = %s, want %s", got, base58Encode(pub))
+	}
+	message := []byte("transaction message")
+	sig, err := signer.SignTransaction(message)
+	if err != nil {
+		t.Fatalf("SignTransaction: %v", err)
+	}
+	if !ed25519.Verify(pub, message, sig) {
+		t.Fatal("signature does not verify against the public key")
+	}
+}
+
+func TestLocalSolanaSignerRejectsBadKeys(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLocalSolanaSigner(base58Encode(priv[:32])); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Fatalf("32-byte secret: err = %v, want ErrInvalidPrivateKey", err)
+	}
+	mismatched := append([]byte{}, priv...)
+	mismatched[40] ^= 1
+	if _, err := NewLocalSolanaSigner(base58Encode(mismatched)); err == nil {
+		t.Fatal("secret whose public half does not match its seed was accepted")
+	}
+}
@@ -0,0 +1,383 @@
+package nova402
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"filippo.io/edwards25519"
+)
+
+// splTransferCheckedInstruction is the SPL token program TransferChecked opcode
+const splTransferCheckedInstruction = 12
+
+// Compute budget program opcodes
+const (
+	computeBudgetSetUnitLimit = 2
+	computeBudgetSetUnitPrice = 3
+)
+
+// solanaAccountMeta describes an account referenced by an instruction
+type solanaAccountMeta struct {
+	PublicKey  []byte
+	IsSigner   bool
+	IsWritable bool
+}
+
+// solanaInstruction represents a single Solana program instruction
+type solanaInstruction struct {
+	ProgramID []byte
+	Accounts  []solanaAccountMeta
+	Data      []byte
+}
+
+// buildSolanaPayload builds a signed SPL token transfer satisfying the requirements
+func (c *Client) buildSolanaPayload(ctx context.Context, requirements PaymentRequirements) (*PaymentPayload, error) {
+	config, err := GetNetworkConfig(requirements.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	mintAddress, err := requirementsAsset(requirements)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := c.TokenDecimalsWithContext(ctx, requirements.Network, mintAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decimals of mint %s: %w", mintAddress, err)
+	}
+
+	signer, err := c.solanaSigner()
+	if err != nil {
+		return nil, err
+	}
+	ownerAddress, err := signer.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	owner, err := decodeSolanaPublicKey(ownerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer public key: %w", err)
+	}
+
+	mint, err := decodeSolanaPublicKey(mintAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mint address: %w", err)
+	}
+	payTo, err := decodeSolanaPublicKey(requirements.PayTo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payTo address: %w", err)
+	}
+
+	value, err := c.paymentValue(ctx, requirements)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+
+	source, err := findAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := findAssociatedTokenAddress(payTo, mint)
+	if err != nil {
+		return nil, err
+	}
+
+	blockhash, err := c.getRecentBlockhash(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent blockhash: %w", err)
+	}
+
+	tokenProgram, _ := decodeSolanaPublicKey(SolanaTokenProgramID)
+	transfer := solanaInstruction{
+		ProgramID: tokenProgram,
+		Accounts: []solanaAccountMeta{
+			{PublicKey: source, IsWritable: true},
+			{PublicKey: mint},
+			{PublicKey: destination, IsWritable: true},
+			{PublicKey: owner, IsSigner: true},
+		},
+		Data: transferCheckedData(amount, uint8(decimals)),
+	}
+
+	instructions := append(c.computeBudgetInstructions(), transfer)
+	message := compileSolanaMessage(owner, instructions, blockhash)
+	signature, err := signer.SignTransaction(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("signer returned %d-byte signature, expected %d", len(signature), ed25519.SignatureSize)
+	}
+
+	tx := appendCompactU16(nil, 1)
+	tx = append(tx, signature...)
+	tx = append(tx, message...)
+
+	encoded := base64Encode(tx)
+	payload := &PaymentPayload{
+		Transaction: &encoded,
+		Signatures:  []string{base58Encode(signature)},
+	}
+	if c.VerifySignatures {
+		valid, err := VerifySolanaPayment(*payload, ownerAddress)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: transaction signature does not verify for %s", ErrInvalidSignature, ownerAddress)
+		}
+	}
+	return payload, nil
+}
+
+// getRecentBlockhash fetches the latest finalized blockhash from a Solana RPC node
+func (c *Client) getRecentBlockhash(ctx context.Context, config *NetworkConfig) ([]byte, error) {
+	var result struct {
+		Value struct {
+			Blockhash string `json:"blockhash"`
+		} `json:"value"`
+	}
+	params := []interface{}{map[string]string{"commitment": "finalized"}}
+	if err := c.callRPC(ctx, config, "getLatestBlockhash", params, &result); err != nil {
+		return nil, err
+	}
+	if result.Value.Blockhash == "" {
+		return nil, fmt.Errorf("rpc returned no blockhash")
+	}
+
+	return decodeSolanaPublicKey(result.Value.Blockhash)
+}
+
+// computeBudgetInstructions returns the compute budget instructions for
+// ComputeUnitLimit and ComputeUnitPrice, omitting those left at zero
+func (c *Client) computeBudgetInstructions() []solanaInstruction {
+	program, _ := decodeSolanaPublicKey(SolanaComputeBudgetProgramID)
+	var instructions []solanaInstruction
+	if c.ComputeUnitLimit > 0 {
+		data := make([]byte, 5)
+		data[0] = computeBudgetSetUnitLimit
+		binary.LittleEndian.PutUint32(data[1:], c.ComputeUnitLimit)
+		instructions = append(instructions, solanaInstruction{ProgramID: program, Data: data})
+	}
+	if c.ComputeUnitPrice > 0 {
+		data := make([]byte, 9)
+		data[0] = computeBudgetSetUnitPrice
+		binary.LittleEndian.PutUint64(data[1:], c.ComputeUnitPrice)
+		instructions = append(instructions, solanaInstruction{ProgramID: program, Data: data})
+	}
+	return instructions
+}
+
+// transferCheckedData encodes SPL token TransferChecked instruction data
+func transferCheckedData(amount uint64, decimals uint8) []byte {
+	data := make([]byte, 10)
+	data[0] = splTransferCheckedInstruction
+	binary.LittleEndian.PutUint64(data[1:9], amount)
+	data[9] = decimals
+	return data
+}
+
+// decodeSolanaPublicKey decodes a base58 Solana address into its 32 raw bytes
+func decodeSolanaPublicKey(address string) ([]byte, error) {
+	key, err := base58Decode(address)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return key, nil
+}
+
+// findAssociatedTokenAddress derives the associated token account for owner and mint
+func findAssociatedTokenAddress(owner, mint []byte) ([]byte, error) {
+	tokenProgram, _ := decodeSolanaPublicKey(SolanaTokenProgramID)
+	ataProgram, _ := decodeSolanaPublicKey(SolanaAssociatedTokenProgramID)
+	return findProgramAddress([][]byte{owner, tokenProgram, mint}, ataProgram)
+}
+
+// findProgramAddress derives a program address that lies off the ed25519 curve
+func findProgramAddress(seeds [][]byte, programID []byte) ([]byte, error) {
+	for bump := 255; bump >= 0; bump-- {
+		h := sha256.New()
+		for _, seed := range seeds {
+			h.Write(seed)
+		}
+		h.Write([]byte{byte(bump)})
+		h.Write(programID)
+		h.Write([]byte("ProgramDerivedAddress"))
+		candidate := h.Sum(nil)
+
+		if _, err := new(edwards25519.Point).SetBytes(candidate); err != nil {
+			return candidate, nil
+		}
+	}
+	return nil, fmt.Errorf("unable to find a viable program address")
+}
+
+// compileSolanaMessage serializes a legacy Solana transaction message
+func compileSolanaMessage(feePayer []byte, instructions []solanaInstruction, blockhash []byte) []byte {
+	type accountEntry struct {
+		key        []byte
+		isSigner   bool
+		isWritable bool
+	}
+
+	var accounts []*accountEntry
+	index := map[string]*accountEntry{}
+	addAccount := func(key []byte, isSigner, isWritable bool) {
+		if entry, ok := index[string(key)]; ok {
+			entry.isSigner = entry.isSigner || isSigner
+			entry.isWritable = entry.isWritable || isWritable
+			return
+		}
+		entry := &accountEntry{key: key, isSigner: isSigner, isWritable: isWritable}
+		index[string(key)] = entry
+		accounts = append(accounts, entry)
+	}
+
+	addAccount(feePayer, true, true)
+	for _, ix := range instructions {
+		for _, meta := range ix.Accounts {
+			addAccount(meta.PublicKey, meta.IsSigner, meta.IsWritable)
+		}
+	}
+	for _, ix := range instructions {
+		addAccount(ix.ProgramID, false, false)
+	}
+
+	// Accounts are ordered: writable signers, readonly signers,
+	// writable non-signers, readonly non-signers
+	var ordered []*accountEntry
+	var numSigners, numReadonlySigned, numReadonlyUnsigned int
+	for _, group := range []struct{ signer, writable bool }{
+		{true, true}, {true, false}, {false, true}, {false, false},
+	} {
+		for _, entry := range accounts {
+			if entry.isSigner != group.signer || entry.isWritable != group.writable {
+				continue
+			}
+			ordered = append(ordered, entry)
+			switch {
+			case group.signer && group.writable:
+				numSigners++
+			case group.signer:
+				numSigners++
+				numReadonlySigned++
+			case !group.writable:
+				numReadonlyUnsigned++
+			}
+		}
+	}
+
+	positions := map[string]byte{}
+	for i, entry := range ordered {
+		positions[string(entry.key)] = byte(i)
+	}
+
+	msg := []byte{byte(numSigners), byte(numReadonlySigned), byte(numReadonlyUnsigned)}
+	msg = appendCompactU16(msg, len(ordered))
+	for _, entry := range ordered {
+		msg = append(msg, entry.key...)
+	}
+	msg = append(msg, blockhash...)
+
+	msg = appendCompactU16(msg, len(instructions))
+	for _, ix := range instructions {
+		msg = append(msg, positions[string(ix.ProgramID)])
+		msg = appendCompactU16(msg, len(ix.Accounts))
+		for _, meta := range ix.Accounts {
+			msg = append(msg, positions[string(meta.PublicKey)])
+		}
+		msg = appendCompactU16(msg, len(ix.Data))
+		msg = append(msg, ix.Data...)
+	}
+
+	return msg
+}
+
+// splitSolanaTransaction splits a serialized transaction into its signatures
+// and the message they sign
+func splitSolanaTransaction(tx []byte) (signatures [][]byte, message []byte, err error) {
+	count, n, err := readCompactU16(tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid signature count: %w", err)
+	}
+	tx = tx[n:]
+	if len(tx) < count*ed25519.SignatureSize {
+		return nil, nil, fmt.Errorf("transaction truncated in signatures")
+	}
+	for i := 0; i < count; i++ {
+		signatures = append(signatures, tx[:ed25519.SignatureSize])
+		tx = tx[ed25519.SignatureSize:]
+	}
+	if len(tx) == 0 {
+		return nil, nil, fmt.Errorf("transaction has no message")
+	}
+	return signatures, tx, nil
+}
+
+// solanaMessageSigners returns the public keys of a message's required
+// signers, the fee payer first. Legacy and versioned messages are accepted.
+func solanaMessageSigners(message []byte) ([][]byte, error) {
+	if len(message) > 0 && message[0]&0x80 != 0 {
+		// Versioned message: the prefix byte precedes the legacy layout
+		message = message[1:]
+	}
+	if len(message) < 3 {
+		return nil, fmt.Errorf("message truncated in header")
+	}
+	numSigners := int(message[0])
+	message = message[3:]
+
+	numAccounts, n, err := readCompactU16(message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account count: %w", err)
+	}
+	message = message[n:]
+	if numSigners > numAccounts || len(message) < numAccounts*ed25519.PublicKeySize {
+		return nil, fmt.Errorf("message truncated in account keys")
+	}
+
+	signers := make([][]byte, numSigners)
+	for i := range signers {
+		signers[i] = message[i*ed25519.PublicKeySize : (i+1)*ed25519.PublicKeySize]
+	}
+	return signers, nil
+}
+
+// readCompactU16 decodes a Solana shortvec-encoded length, returning it and
+// the number of bytes it took
+func readCompactU16(b []byte) (int, int, error) {
+	value := 0
+	for i := 0; i < 3; i++ {
+		if i >= len(b) {
+			return 0, 0, fmt.Errorf("unexpected end of data")
+		}
+		value |= int(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return value, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("length exceeds 16 bits")
+}
+
+// appendCompactU16 appends a Solana shortvec-encoded length
+func appendCompactU16(b []byte, n int) []byte {
+	for {
+		elem := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(b, elem)
+		}
+		b = append(b, elem|0x80)
+	}
+}
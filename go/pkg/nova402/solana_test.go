@@ -0,0 +1,36 @@
+package nova402
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestComputeBudgetInstructions(t *testing.T) {
+	program, err := decodeSolanaPublicKey(SolanaComputeBudgetProgramID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient("solana-devnet", "", WithComputeUnitPrice(1000), WithComputeUnitLimit(200000))
+	instructions := c.computeBudgetInstructions()
+	if len(instructions) != 2 {
+		t.Fatalf("got %d compute budget instructions, want 2", len(instructions))
+	}
+	// SetComputeUnitLimit(200000) as a u32, then SetComputeUnitPrice(1000) as a u64
+	if data := instructions[0].Data; !bytes.Equal(data, []byte{2, 0x40, 0x0d, 0x03, 0}) {
+		t.Fatalf("limit instruction data = %x", data)
+	}
+	if data := instructions[1].Data; !bytes.Equal(data, []byte{3, 0xe8, 3, 0, 0, 0, 0, 0, 0}) {
+		t.Fatalf("price instruction data = %x", data)
+	}
+	for _, instruction := range instructions {
+		if !bytes.Equal(instruction.ProgramID, program) {
+			t.Fatalf("instruction program = %x, want the compute budget program", instruction.ProgramID)
+		}
+	}
+}
+
+func TestComputeBudgetInstructionsDefault(t *testing.T) {
+	if instructions := NewClient("solana-devnet", "").computeBudgetInstructions(); len(instructions) != 0 {
+		t.Fatalf("got %d compute budget instructions without options, want 0", len(instructions))
+	}
+}
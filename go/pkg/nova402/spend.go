@@ -0,0 +1,359 @@
+package nova402
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// SpendTracker keeps a running total of the base-unit amounts a client has
+// paid on each network. It is safe for concurrent use and may be shared
+// between clients to track a combined budget.
+type SpendTracker struct {
+	mu     sync.RWMutex
+	totals map[string]*big.Int
+}
+
+// NewSpendTracker creates a tracker with nothing spent
+func NewSpendTracker() *SpendTracker {
+	return &SpendTracker{totals: make(map[string]*big.Int)}
+}
+
+// Add records amount as spent on network. Network aliases are counted under
+// the network they resolve to.
+func (t *SpendTracker) Add(network string, amount *big.Int) {
+	if amount == nil || amount.Sign() <= 0 {
+		return
+	}
+	network = canonicalNetwork(network)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.totals == nil {
+		t.totals = make(map[string]*big.Int)
+	}
+	total, exists := t.totals[network]
+	if !exists {
+		total = new(big.Int)
+		t.totals[network] = total
+	}
+	total.Add(total, amount)
+}
+
+// TotalSpent returns the base-unit amount spent on network since the tracker
+// was created or last reset. It fails with ErrUnsupportedNetwork for networks
+// that are not registered.
+func (t *SpendTracker) TotalSpent(network string) (*big.Int, error) {
+	if _, err := GetNetworkConfig(network); err != nil {
+		return nil, err
+	}
+	network = canonicalNetwork(network)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if total, exists := t.totals[network]; exists {
+		return new(big.Int).Set(total), nil
+	}
+	return new(big.Int), nil
+}
+
+// Subtract removes amount from what has been spent on network, never taking
+// the total below zero. It undoes Add for a payment that did not go through.
+func (t *SpendTracker) Subtract(network string, amount *big.Int) {
+	if amount == nil || amount.Sign() <= 0 {
+		return
+	}
+	network = canonicalNetwork(network)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	total, exists := t.totals[network]
+	if !exists {
+		return
+	}
+	total.Sub(total, amount)
+	if total.Sign() < 0 {
+		total.SetInt64(0)
+	}
+}
+
+// Reset clears the totals of every network
+func (t *SpendTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.totals = make(map[string]*big.Int)
+}
+
+// recordSpend adds a confirmed payment to SpendTracker and to the window
+// DailyCaps are checked against, returning the amount recorded. Amounts that
+// cannot be parsed are logged and skipped, since the payment has already gone
+// out.
+func (c *Client) recordSpend(ctx context.Context, network, amount string) *big.Int {
+	network = canonicalNetwork(network)
+	capped := networkLimit(c.DailyCaps, network) != nil
+	if c.SpendTracker == nil && !capped {
+		return nil
+	}
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		c.logger().WarnContext(ctx, "x402: cannot track spend",
+			slog.String("network", network),
+			slog.String("amount", amount))
+		return nil
+	}
+	if c.SpendTracker != nil {
+		c.SpendTracker.Add(network, value)
+	}
+	if capped {
+		c.capWindow.add(network, value, time.Now(), c.capInterval())
+	}
+	return value
+}
+
+// commitSpend records a successful payment, turning its cap reservation
+// into recorded spend, and returns the spend to hold for CancelPayment. A
+// payment with no reservation is recorded through recordSpend.
+func (c *Client) commitSpend(ctx context.Context, network, amount string, reservation *heldSpend) *heldSpend {
+	if reservation == nil {
+		value := c.recordSpend(ctx, network, amount)
+		if value == nil {
+			return nil
+		}
+		return &heldSpend{network: canonicalNetwork(network), amount: value, recordedAt: time.Now()}
+	}
+	if c.SpendTracker != nil {
+		c.SpendTracker.Add(reservation.network, reservation.amount)
+	}
+	return reservation
+}
+
+// networkLimit returns the entry of limits for network, whether its key is
+// the network's registered name or an alias in any case
+func networkLimit(limits map[string]*big.Int, network string) *big.Int {
+	network = canonicalNetwork(network)
+	if limit, exists := limits[network]; exists {
+		return limit
+	}
+	for key, limit := range limits {
+		if canonicalNetwork(key) == network {
+			return limit
+		}
+	}
+	return nil
+}
+
+// releaseSpend takes back spend recorded at the given time by recordSpend.
+// Spend recorded in an earlier cap window has already been reset and is only
+// removed from SpendTracker.
+func (c *Client) releaseSpend(network string, amount *big.Int, recordedAt time.Time) {
+	network = canonicalNetwork(network)
+	if c.SpendTracker != nil {
+		c.SpendTracker.Subtract(network, amount)
+	}
+	c.capWindow.sub(network, amount, recordedAt, time.Now(), c.capInterval())
+}
+
+// heldSpends remembers the spend recorded for stored payments that have not
+// reached a final status, so that CancelPayment can release it. The zero
+// value is ready to use.
+type heldSpends struct {
+	mu      sync.Mutex
+	entries map[string]heldSpend
+}
+
+// heldSpend is an amount recorded, or reserved, against network's cap window
+type heldSpend struct {
+	network    string
+	amount     *big.Int
+	recordedAt time.Time
+}
+
+func (h *heldSpends) put(paymentID string, spend heldSpend) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.entries == nil {
+		h.entries = make(map[string]heldSpend)
+	}
+	h.entries[paymentID] = spend
+}
+
+// take removes and returns the spend held for paymentID
+func (h *heldSpends) take(paymentID string) (heldSpend, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	spend, ok := h.entries[paymentID]
+	delete(h.entries, paymentID)
+	return spend, ok
+}
+
+// TotalSpent returns the amount spent on network as recorded by the client's
+// SpendTracker
+func (c *Client) TotalSpent(network string) (*big.Int, error) {
+	if c.SpendTracker == nil {
+		return nil, fmt.Errorf("no spend tracker configured")
+	}
+	return c.SpendTracker.TotalSpent(network)
+}
+
+// DefaultCapInterval is the window DailyCaps apply to when CapInterval is unset
+const DefaultCapInterval = 24 * time.Hour
+
+// spendWindow totals spend per network within the current cap window. The
+// window starts at the first payment and restarts every interval; the zero
+// value is ready to use.
+type spendWindow struct {
+	mu    sync.Mutex
+	start time.Time
+	spent map[string]*big.Int
+}
+
+// roll starts a new window when the current one has run for interval. It must
+// be called with mu held.
+func (w *spendWindow) roll(now time.Time, interval time.Duration) {
+	if w.start.IsZero() {
+		w.start = now
+		return
+	}
+	if elapsed := now.Sub(w.start); elapsed >= interval {
+		w.start = w.start.Add(elapsed / interval * interval)
+		w.spent = nil
+	}
+}
+
+func (w *spendWindow) add(network string, amount *big.Int, now time.Time, interval time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roll(now, interval)
+	w.addLocked(network, amount)
+}
+
+// reserve adds amount to network's spend only if that keeps it within limit.
+// It returns the spend the window would reach and when the window resets.
+func (w *spendWindow) reserve(network string, amount, limit *big.Int, now time.Time, interval time.Duration) (*big.Int, time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roll(now, interval)
+	reached := new(big.Int).Set(amount)
+	if total, exists := w.spent[network]; exists {
+		reached.Add(reached, total)
+	}
+	if reached.Cmp(limit) > 0 {
+		return reached, w.start.Add(interval), false
+	}
+	w.addLocked(network, amount)
+	return reached, w.start.Add(interval), true
+}
+
+// addLocked adds amount to network's spend. It must be called with mu held.
+func (w *spendWindow) addLocked(network string, amount *big.Int) {
+	if w.spent == nil {
+		w.spent = make(map[string]*big.Int)
+	}
+	total, exists := w.spent[network]
+	if !exists {
+		total = new(big.Int)
+		w.spent[network] = total
+	}
+	total.Add(total, amount)
+}
+
+// sub removes amount recorded at recordedAt from network's spend, unless the
+// window it was recorded in has since been reset
+func (w *spendWindow) sub(network string, amount *big.Int, recordedAt, now time.Time, interval time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roll(now, interval)
+	total, exists := w.spent[network]
+	if !exists || recordedAt.Before(w.start) {
+		return
+	}
+	total.Sub(total, amount)
+	if total.Sign() < 0 {
+		total.SetInt64(0)
+	}
+}
+
+// total returns what has been spent on network in the current window and when
+// the window resets
+func (w *spendWindow) total(network string, now time.Time, interval time.Duration) (*big.Int, time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roll(now, interval)
+	spent := new(big.Int)
+	if total, exists := w.spent[network]; exists {
+		spent.Set(total)
+	}
+	return spent, w.start.Add(interval)
+}
+
+// capInterval returns CapInterval, defaulting to DefaultCapInterval
+func (c *Client) capInterval() time.Duration {
+	if c.CapInterval > 0 {
+		return c.CapInterval
+	}
+	return DefaultCapInterval
+}
+
+// checkSpendCap fails with ErrBudgetExceeded when paying amount on network
+// would take the current window's spend over the network's DailyCaps entry.
+// It rejects a payment before anything is signed; reserveSpend enforces the
+// cap when the payment is sent.
+func (c *Client) checkSpendCap(network, amount string) error {
+	network = canonicalNetwork(network)
+	limit := networkLimit(c.DailyCaps, network)
+	if limit == nil {
+		return nil
+	}
+
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %q", amount)
+	}
+	spent, resetAt := c.capWindow.total(network, time.Now(), c.capInterval())
+	if reached := new(big.Int).Add(spent, value); reached.Cmp(limit) > 0 {
+		return budgetExceeded(network, value, reached, limit, resetAt)
+	}
+	return nil
+}
+
+// reserveSpend counts amount against network's DailyCaps entry before the
+// payment is sent, failing with ErrBudgetExceeded when the current window has
+// no room for it. The check and the reservation are made under one lock, so
+// concurrent payments can never together exceed the cap. The reservation
+// becomes the payment's recorded spend once it succeeds and must be released
+// with releaseReservation otherwise. It returns nil for uncapped networks.
+func (c *Client) reserveSpend(network, amount string) (*heldSpend, error) {
+	network = canonicalNetwork(network)
+	limit := networkLimit(c.DailyCaps, network)
+	if limit == nil {
+		return nil, nil
+	}
+
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	now := time.Now()
+	reached, resetAt, ok := c.capWindow.reserve(network, value, limit, now, c.capInterval())
+	if !ok {
+		return nil, budgetExceeded(network, value, reached, limit, resetAt)
+	}
+	return &heldSpend{network: network, amount: value, recordedAt: now}, nil
+}
+
+// releaseReservation takes back a reservation made by reserveSpend for a
+// payment that did not go through
+func (c *Client) releaseReservation(reservation *heldSpend) {
+	if reservation == nil {
+		return
+	}
+	c.capWindow.sub(reservation.network, reservation.amount, reservation.recordedAt, time.Now(), c.capInterval())
+}
+
+func budgetExceeded(network string, value, reached, limit *big.Int, resetAt time.Time) error {
+	return fmt.Errorf("%w: paying %s would bring %s spend to %s of %s cap, resets at %s",
+		ErrBudgetExceeded, value, network, reached, limit, resetAt.Format(time.RFC3339))
+}
@@ -0,0 +1,195 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// aliasServer answers unpaid requests with a 402 asking for amount on
+// network, and paid ones with 200
+func aliasServer(network, amount string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-PAYMENT") != "" {
+			w.Write([]byte("ok"))
+			return
+		}
+		WritePayment402(w, NewPayment402Response(PaymentRequirements{
+			Scheme:            "exact",
+			Network:           network,
+			MaxAmountRequired: amount,
+			PayTo:             "0x209693Bc6afc0C5328bA36FaF03C514EF312287C",
+			MaxTimeoutSeconds: 60,
+			Resource:          "https://example.com/resource",
+		}))
+	}))
+}
+
+func TestCapsApplyToNetworkAliases(t *testing.T) {
+	for _, network := range []string{"base-mainnet", "base", "BASE"} {
+		t.Run(network, func(t *testing.T) {
+			srv := aliasServer(network, "1000000000")
+			defer srv.Close()
+
+			c := NewClientWithOptions(WithNetwork("base-mainnet"), WithPrivateKey(testKey),
+				WithMaxPaymentAmount("base-mainnet", big.NewInt(1)))
+			if _, err := c.GetPaid(context.Background(), srv.URL, nil); !errors.Is(err, ErrAmountExceedsLimit) {
+				t.Fatalf("MaxPaymentAmount: got %v, want ErrAmountExceedsLimit", err)
+			}
+
+			c = NewClientWithOptions(WithNetwork("base-mainnet"), WithPrivateKey(testKey),
+				WithDailyCap("BASE", big.NewInt(1)))
+			if _, err := c.GetPaid(context.Background(), srv.URL, nil); !errors.Is(err, ErrBudgetExceeded) {
+				t.Fatalf("DailyCaps: got %v, want ErrBudgetExceeded", err)
+			}
+		})
+	}
+}
+
+func TestSpendTrackedUnderCanonicalNetwork(t *testing.T) {
+	srv := aliasServer("base", "1000")
+	defer srv.Close()
+
+	c := NewClientWithOptions(WithNetwork("base-mainnet"), WithPrivateKey(testKey),
+		WithDailyCap("base-mainnet", big.NewInt(1500)))
+	if _, err := c.GetPaid(context.Background(), srv.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	total, err := c.TotalSpent("base-mainnet")
+	if err != nil || total.String() != "1000" {
+		t.Fatalf("TotalSpent = %v, %v; want 1000", total, err)
+	}
+	if _, err := c.GetPaid(context.Background(), srv.URL, nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("second payment: got %v, want ErrBudgetExceeded", err)
+	}
+}
+
+func TestTotalSpent(t *testing.T) {
+	srv := paidServer(nil)
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get(srv.URL, nil)
+		if err != nil {
+			t.Fatalf("Get %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	total, err := c.TotalSpent("base-sepolia")
+	if err != nil || total.Cmp(big.NewInt(3000)) != 0 {
+		t.Fatalf("TotalSpent = %v, %v; want 3000", total, err)
+	}
+	if _, err := c.SpendTracker.TotalSpent("not-a-network"); err == nil {
+		t.Fatal("TotalSpent accepted an unknown network")
+	}
+
+	c.SpendTracker.Reset()
+	if total, _ := c.TotalSpent("base-sepolia"); total.Sign() != 0 {
+		t.Fatalf("TotalSpent after Reset = %v, want 0", total)
+	}
+}
+
+func TestSpendTrackerConcurrentAdd(t *testing.T) {
+	tracker := NewSpendTracker()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.Add("base-mainnet", big.NewInt(1))
+		}()
+	}
+	wg.Wait()
+	if total, _ := tracker.TotalSpent("base-mainnet"); total.Int64() != 100 {
+		t.Fatalf("TotalSpent = %v, want 100", total)
+	}
+}
+
+func TestDailyCapBlocksThenResets(t *testing.T) {
+	srv := paidServer(nil)
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithDailyCap("base-sepolia", big.NewInt(2500)), WithCapInterval(200*time.Millisecond)).WithPrivateKey(testKey)
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(srv.URL, nil)
+		if err != nil {
+			t.Fatalf("Get %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	// A third payment of 1000 would take the window to 3000
+	if _, err := c.Get(srv.URL, nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("payment over the cap: err = %v, want ErrBudgetExceeded", err)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	resp, err := c.Get(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Get after the cap window reset: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestDailyCapHoldsUnderConcurrentPayments(t *testing.T) {
+	// Hold each paid request so every payment passes the pre-signing check
+	// while the others are still in flight
+	srv := paidServer(func(w http.ResponseWriter) { time.Sleep(50 * time.Millisecond) })
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithDailyCap("base-sepolia", big.NewInt(2500))).WithPrivateKey(testKey)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		paid     int
+		rejected int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.Get(srv.URL, nil)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				resp.Body.Close()
+				paid++
+			case errors.Is(err, ErrBudgetExceeded):
+				rejected++
+			default:
+				t.Errorf("Get: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if paid != 2 || rejected != 8 {
+		t.Fatalf("paid %d and rejected %d payments, want 2 and 8", paid, rejected)
+	}
+	if spent, _ := c.capWindow.total("base-sepolia", time.Now(), c.capInterval()); spent.Int64() != 2000 {
+		t.Fatalf("cap window spend = %v, want 2000", spent)
+	}
+}
+
+func TestDailyCapReleasesFailedPayment(t *testing.T) {
+	srv := paidServer(func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadRequest) })
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "", WithDailyCap("base-sepolia", big.NewInt(1000))).WithPrivateKey(testKey)
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(srv.URL, nil)
+		if err != nil {
+			t.Fatalf("Get %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if spent, _ := c.capWindow.total("base-sepolia", time.Now(), c.capInterval()); spent.Sign() != 0 {
+		t.Fatalf("cap window spend after failed payments = %v, want 0", spent)
+	}
+}
@@ -0,0 +1,148 @@
+package nova402
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// statusPollInterval is how often WaitForStatus polls the facilitator
+const statusPollInterval = 2 * time.Second
+
+// PaymentStatusFacilitator is implemented by facilitators that can report the
+// status of a payment they verified or settled
+type PaymentStatusFacilitator interface {
+	PaymentStatus(ctx context.Context, paymentID string) (*Payment, error)
+}
+
+// PaymentStatus fetches a payment from the facilitator's /payments/{id} endpoint
+func (f *HTTPFacilitator) PaymentStatus(ctx context.Context, paymentID string) (*Payment, error) {
+	path := "/payments/" + url.PathEscape(paymentID)
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(f.URL, "/")+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var payment Payment
+	if err := f.do(req, path, &payment); err != nil {
+		return nil, err
+	}
+	return normalizePayment(&payment, time.Now())
+}
+
+// PaymentStatus asks the facilitator for the current state of a payment. The
+// payment ID is either the authorization nonce, as set by NewPayment, or the
+// ID of a payment in the PaymentStore, such as PaidResponse.PaymentID, which
+// is looked up by its nonce.
+func (c *Client) PaymentStatus(paymentID string) (*Payment, error) {
+	return c.PaymentStatusWithContext(context.Background(), paymentID)
+}
+
+// PaymentStatusWithContext is PaymentStatus with a caller-supplied context
+func (c *Client) PaymentStatusWithContext(ctx context.Context, paymentID string) (*Payment, error) {
+	facilitator, ok := c.facilitator().(PaymentStatusFacilitator)
+	if !ok {
+		return nil, fmt.Errorf("%w: facilitator does not report payment status", ErrNotImplemented)
+	}
+	paymentID = c.facilitatorPaymentID(paymentID)
+
+	var payment *Payment
+	err := c.withRetry(ctx, func() error {
+		if err := c.waitRateLimit(ctx); err != nil {
+			return err
+		}
+		var err error
+		payment, err = facilitator.PaymentStatus(ctx, paymentID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// WaitForStatus polls the facilitator until the payment reaches target. It
+// returns the payment with an error if it settles into a different final
+// state, and ErrStatusTimeout if timeout elapses first.
+func (c *Client) WaitForStatus(paymentID string, target PaymentStatus, timeout time.Duration) (*Payment, error) {
+	return c.WaitForStatusWithContext(context.Background(), paymentID, target, timeout)
+}
+
+// WaitForStatusWithContext is WaitForStatus with a caller-supplied context.
+// Cancelling ctx stops the wait with ctx's error.
+func (c *Client) WaitForStatusWithContext(parent context.Context, paymentID string, target PaymentStatus, timeout time.Duration) (*Payment, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+
+	for {
+		payment, err := c.PaymentStatusWithContext(ctx, paymentID)
+		if err != nil {
+			if parent.Err() != nil {
+				return nil, parent.Err()
+			}
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w: payment %s", ErrStatusTimeout, paymentID)
+			}
+			return nil, err
+		}
+		if payment.Status == target {
+			return payment, nil
+		}
+		if payment.Status.IsFinal() {
+			return payment, fmt.Errorf("payment %s is %s, not %s", paymentID, payment.Status, target)
+		}
+
+		select {
+		case <-ctx.Done():
+			if parent.Err() != nil {
+				return payment, parent.Err()
+			}
+			return payment, fmt.Errorf("%w: payment %s is still %s", ErrStatusTimeout, paymentID, payment.Status)
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsFinal reports whether a payment in this status can no longer change
+func (s PaymentStatus) IsFinal() bool {
+	return s == StatusConfirmed || s == StatusFailed || s == StatusExpired
+}
+
+// paymentStatusAliases maps status names used by facilitators onto PaymentStatus
+var paymentStatusAliases = map[string]PaymentStatus{
+	"pending":    StatusPending,
+	"created":    StatusPending,
+	"verified":   StatusPending,
+	"processing": StatusProcessing,
+	"submitted":  StatusProcessing,
+	"settling":   StatusProcessing,
+	"confirmed":  StatusConfirmed,
+	"settled":    StatusConfirmed,
+	"completed":  StatusConfirmed,
+	"success":    StatusConfirmed,
+	"failed":     StatusFailed,
+	"error":      StatusFailed,
+	"reverted":   StatusFailed,
+	"expired":    StatusExpired,
+	"timeout":    StatusExpired,
+}
+
+// normalizePayment maps the facilitator's status onto PaymentStatus. A payment
+// still pending after its ExpiresAt can never settle and is reported expired.
+func normalizePayment(payment *Payment, now time.Time) (*Payment, error) {
+	status, exists := paymentStatusAliases[strings.ToLower(string(payment.Status))]
+	if !exists {
+		return nil, fmt.Errorf("unknown payment status %q", payment.Status)
+	}
+	if status == StatusPending && !payment.ExpiresAt.IsZero() && now.After(payment.ExpiresAt) {
+		status = StatusExpired
+	}
+	payment.Status = status
+	return payment, nil
+}
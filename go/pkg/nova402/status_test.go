@@ -0,0 +1,85 @@
+package nova402
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForStatus(t *testing.T) {
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/payments/0xab" {
+			t.Errorf("polled %s, want /payments/0xab", r.URL.Path)
+		}
+		if polls.Add(1) == 1 {
+			w.Write([]byte(`{"id":"0xab","status":"processing"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"0xab","status":"settled","txHash":"0x1"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", srv.URL)
+	payment, err := c.WaitForStatus("0xab", StatusConfirmed, 5*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForStatus: %v", err)
+	}
+	if payment.Status != StatusConfirmed || polls.Load() != 2 {
+		t.Fatalf("status %s after %d polls, want confirmed after 2", payment.Status, polls.Load())
+	}
+}
+
+func TestWaitForStatusTimesOut(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"0xab","status":"pending"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", srv.URL)
+	if _, err := c.WaitForStatus("0xab", StatusConfirmed, 100*time.Millisecond); !errors.Is(err, ErrStatusTimeout) {
+		t.Fatalf("err = %v, want ErrStatusTimeout", err)
+	}
+}
+
+func TestNormalizePaymentExpires(t *testing.T) {
+	now := time.Now()
+	payment, err := normalizePayment(&Payment{Status: "pending", ExpiresAt: now.Add(-time.Second)}, now)
+	if err != nil {
+		t.Fatalf("normalizePayment: %v", err)
+	}
+	if payment.Status != StatusExpired {
+		t.Fatalf("status = %s, want expired once ExpiresAt has passed", payment.Status)
+	}
+}
+
+func TestPaymentStatusMapsStoreID(t *testing.T) {
+	store := NewMemoryPaymentStore()
+	if err := store.Save(Payment{ID: "record-1", Status: StatusProcessing, Metadata: map[string]interface{}{facilitatorIDKey: "0xab"}}); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		path = r.URL.Path
+		mu.Unlock()
+		w.Write([]byte(`{"id":"0xab","status":"settled"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", srv.URL, WithPaymentStore(store))
+	payment, err := c.PaymentStatus("record-1")
+	if err != nil {
+		t.Fatalf("PaymentStatus: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if payment.Status != StatusConfirmed || path != "/payments/0xab" {
+		t.Fatalf("status %s from %s, want confirmed from /payments/0xab", payment.Status, path)
+	}
+}
@@ -0,0 +1,211 @@
+package nova402
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// PaymentStore persists a record of every payment the client sends, for
+// audit trails and reconciliation against on-chain data. Implement it to keep
+// the ledger in a database.
+type PaymentStore interface {
+	// Save creates or replaces the payment with the same ID
+	Save(payment Payment) error
+	// Get returns the payment with id, or ErrPaymentNotFound
+	Get(id string) (*Payment, error)
+}
+
+// MemoryPaymentStore is an in-memory PaymentStore safe for concurrent use
+type MemoryPaymentStore struct {
+	mu       sync.RWMutex
+	payments map[string]Payment
+}
+
+// NewMemoryPaymentStore creates an empty in-memory payment store
+func NewMemoryPaymentStore() *MemoryPaymentStore {
+	return &MemoryPaymentStore{payments: make(map[string]Payment)}
+}
+
+// Save creates or replaces the payment with the same ID
+func (s *MemoryPaymentStore) Save(payment Payment) error {
+	if payment.ID == "" {
+		return fmt.Errorf("payment has no ID")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payments[payment.ID] = copyPayment(payment)
+	return nil
+}
+
+// Get returns the payment with id, or ErrPaymentNotFound
+func (s *MemoryPaymentStore) Get(id string) (*Payment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	payment, exists := s.payments[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentNotFound, id)
+	}
+	payment = copyPayment(payment)
+	return &payment, nil
+}
+
+// copyPayment returns payment with its TxHash and Metadata detached from the
+// original so stored records cannot be changed through the caller's copy
+func copyPayment(payment Payment) Payment {
+	if payment.TxHash != nil {
+		txHash := *payment.TxHash
+		payment.TxHash = &txHash
+	}
+	if payment.Metadata != nil {
+		metadata := make(map[string]interface{}, len(payment.Metadata))
+		for k, v := range payment.Metadata {
+			metadata[k] = v
+		}
+		payment.Metadata = metadata
+	}
+	return payment
+}
+
+// newPaymentID returns a random 16-byte payment ID as hex
+func newPaymentID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate payment ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// facilitatorIDKey is the Metadata key of the ID a facilitator knows a
+// recorded payment by. Only EIP-3009 payments have one: their nonce.
+const facilitatorIDKey = "facilitatorPaymentId"
+
+// facilitatorPaymentID returns the ID the facilitator knows a payment by.
+// The ID of a payment recorded in the PaymentStore is mapped to its
+// authorization nonce; any other ID is assumed to be a nonce already.
+func (c *Client) facilitatorPaymentID(id string) string {
+	if c.PaymentStore == nil {
+		return id
+	}
+	record, err := c.PaymentStore.Get(id)
+	if err != nil {
+		return id
+	}
+	if nonce, ok := record.Metadata[facilitatorIDKey].(string); ok && nonce != "" {
+		return nonce
+	}
+	return id
+}
+
+// paymentRecord builds the pending Payment recorded for a signed payment.
+// The ID is always freshly generated, since reused upto authorizations share
+// a nonce across payments.
+func (c *Client) paymentRecord(ctx context.Context, payment *PaymentHeader, requirements *PaymentRequirements, now time.Time) (Payment, error) {
+	id, err := newPaymentID()
+	if err != nil {
+		return Payment{}, err
+	}
+
+	var paid PaymentRequirements
+	if requirements != nil {
+		paid = *requirements
+	}
+	paid.Network = payment.Network
+
+	var record Payment
+	if auth := payment.Payload.Authorization; auth != nil {
+		record = NewPayment(paid, *auth, now)
+		record.Metadata = map[string]interface{}{"nonce": auth.Nonce, facilitatorIDKey: auth.Nonce}
+	} else if permit := payment.Payload.Permit; permit != nil {
+		record = Payment{
+			From:      permit.Owner,
+			To:        paid.PayTo,
+			Amount:    permit.Value,
+			Network:   payment.Network,
+			Status:    StatusPending,
+			CreatedAt: now,
+			ExpiresAt: time.Unix(permit.Deadline, 0),
+			Metadata:  map[string]interface{}{"spender": permit.Spender, "nonce": permit.Nonce},
+		}
+	} else {
+		_, validBefore := paid.ValidityWindow(now)
+		record = Payment{
+			To:        paid.PayTo,
+			Amount:    c.paidAmount(ctx, payment, requirements),
+			Network:   payment.Network,
+			Status:    StatusPending,
+			CreatedAt: now,
+			ExpiresAt: time.Unix(validBefore, 0),
+		}
+		if signer, err := c.solanaSigner(); err == nil {
+			record.From, _ = signer.PublicKey()
+		}
+	}
+	record.ID = id
+	return record, nil
+}
+
+// recordPayment saves a pending record of payment to the PaymentStore.
+// Nothing is recorded, and nil is returned, when no store is configured.
+func (c *Client) recordPayment(ctx context.Context, payment *PaymentHeader, requirements *PaymentRequirements) (*Payment, error) {
+	if c.PaymentStore == nil {
+		return nil, nil
+	}
+
+	record, err := c.paymentRecord(ctx, payment, requirements, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := c.PaymentStore.Save(record); err != nil {
+		return nil, fmt.Errorf("failed to record payment: %w", err)
+	}
+	c.notifyStatusChange(ctx, record, "")
+	return &record, nil
+}
+
+// updatePayment moves record to status, or to expired once past its
+// ExpiresAt, and logs rather than returns store failures
+func (c *Client) updatePayment(ctx context.Context, record *Payment, status PaymentStatus, txHash *string) {
+	if record == nil || record.Status.IsFinal() {
+		return
+	}
+	if !status.IsFinal() && !record.ExpiresAt.IsZero() && time.Now().After(record.ExpiresAt) {
+		status = StatusExpired
+	}
+	if record.Status == status {
+		return
+	}
+
+	previous := record.Status
+	record.Status = status
+	if status.IsFinal() {
+		c.heldSpends.take(record.ID)
+	}
+	if txHash != nil {
+		record.TxHash = txHash
+	}
+	if err := c.PaymentStore.Save(*record); err != nil {
+		c.logger().WarnContext(ctx, "x402: failed to update payment record",
+			slog.String("id", record.ID),
+			slog.String("status", string(status)),
+			slog.String("error", err.Error()))
+	}
+	c.notifyStatusChange(ctx, *record, previous)
+}
+
+// notifyStatusChange logs a status transition and passes it to OnStatusChange
+func (c *Client) notifyStatusChange(ctx context.Context, record Payment, previous PaymentStatus) {
+	c.logger().DebugContext(ctx, "x402: payment status changed",
+		slog.String("id", record.ID),
+		slog.String("from", string(previous)),
+		slog.String("to", string(record.Status)))
+	if c.OnStatusChange != nil {
+		c.OnStatusChange(copyPayment(record), previous)
+	}
+}
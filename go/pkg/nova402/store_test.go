@@ -0,0 +1,162 @@
+package nova402
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryPaymentStore(t *testing.T) {
+	store := NewMemoryPaymentStore()
+	txHash := "0xabc"
+	payment := Payment{ID: "p1", Amount: "1000", Status: StatusConfirmed, TxHash: &txHash, Metadata: map[string]interface{}{"k": "v"}}
+	if err := store.Save(payment); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Changing the caller's copy must not change the stored record
+	txHash = "0xchanged"
+	payment.Metadata["k"] = "changed"
+	got, err := store.Get("p1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if *got.TxHash != "0xabc" || got.Metadata["k"] != "v" {
+		t.Fatalf("stored record changed through the caller's copy: %+v", got)
+	}
+	got.Metadata["k"] = "changed"
+	if again, _ := store.Get("p1"); again.Metadata["k"] != "v" {
+		t.Fatal("stored record changed through a returned copy")
+	}
+
+	if _, err := store.Get("missing"); !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("Get missing: err = %v, want ErrPaymentNotFound", err)
+	}
+	if err := store.Save(Payment{}); err == nil {
+		t.Fatal("payment without an ID saved")
+	}
+}
+
+func TestClientRecordsPayment(t *testing.T) {
+	srv := paidServer(nil)
+	defer srv.Close()
+
+	store := NewMemoryPaymentStore()
+	c := NewClient("base-sepolia", "", WithPaymentStore(store)).WithPrivateKey(testKey)
+	before := time.Now()
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	paid.Response.Body.Close()
+	if paid.PaymentID == "" {
+		t.Fatal("GetPaid returned no PaymentID")
+	}
+
+	record, err := store.Get(paid.PaymentID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	from, _ := AddressFromPrivateKey(testKey)
+	if record.From != from || record.To != "0x209693Bc6afc0C5328bA36FaF03C514EF312287C" ||
+		record.Amount != "1000" || record.Network != "base-sepolia" {
+		t.Fatalf("record = %+v, want the payment of 1000 from %s", record, from)
+	}
+	if record.CreatedAt.Before(before.Add(-time.Second)) || !record.ExpiresAt.After(record.CreatedAt) {
+		t.Fatalf("record timestamps created %v expires %v", record.CreatedAt, record.ExpiresAt)
+	}
+}
+
+// settlingServer is a resource server that settles each payment through the
+// facilitator at facilitatorURL and reports the result in X-PAYMENT-RESPONSE
+func settlingServer(t *testing.T, facilitatorURL string) *httptest.Server {
+	settler := NewClient("base-sepolia", facilitatorURL)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header, err := ParsePaymentHeader(r.Header.Get("X-PAYMENT"))
+		if err != nil {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(paid402))
+			return
+		}
+		result, err := settler.Settle(*header, testRequirements())
+		var settlementErr *SettlementError
+		if err != nil && !errors.As(err, &settlementErr) {
+			t.Errorf("Settle: %v", err)
+			return
+		}
+		encoded, _ := json.Marshal(result)
+		w.Header().Set(PaymentResponseHeader, base64Encode(encoded))
+	}))
+}
+
+func TestPaymentStatusLifecycle(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		settlement string
+		want       []PaymentStatus
+	}{
+		{"settled", `{"success":true,"txHash":"0xabc"}`, []PaymentStatus{StatusPending, StatusProcessing, StatusConfirmed}},
+		{"rejected", `{"success":false,"error":"insufficient funds"}`, []PaymentStatus{StatusPending, StatusProcessing, StatusFailed}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fac := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.settlement))
+			}))
+			defer fac.Close()
+			srv := settlingServer(t, fac.URL)
+			defer srv.Close()
+
+			var mu sync.Mutex
+			var transitions []PaymentStatus
+			store := NewMemoryPaymentStore()
+			c := NewClient("base-sepolia", "", WithPaymentStore(store), WithStatusChangeHandler(func(payment Payment, previous PaymentStatus) {
+				mu.Lock()
+				defer mu.Unlock()
+				transitions = append(transitions, payment.Status)
+			})).WithPrivateKey(testKey)
+			paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+			if err != nil {
+				t.Fatalf("GetPaid: %v", err)
+			}
+			paid.Response.Body.Close()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(transitions, tc.want) {
+				t.Fatalf("transitions = %v, want %v", transitions, tc.want)
+			}
+			record, err := store.Get(paid.PaymentID)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if record.Status != tc.want[len(tc.want)-1] {
+				t.Fatalf("stored status = %s, want %s", record.Status, tc.want[len(tc.want)-1])
+			}
+			if record.Status == StatusConfirmed && (record.TxHash == nil || *record.TxHash != "0xabc") {
+				t.Fatalf("confirmed record TxHash = %v, want 0xabc", record.TxHash)
+			}
+		})
+	}
+}
+
+func TestPaymentStatusWithoutSettlementStaysProcessing(t *testing.T) {
+	srv := paidServer(nil)
+	defer srv.Close()
+
+	store := NewMemoryPaymentStore()
+	c := NewClient("base-sepolia", "", WithPaymentStore(store)).WithPrivateKey(testKey)
+	paid, err := c.GetPaid(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatalf("GetPaid: %v", err)
+	}
+	paid.Response.Body.Close()
+	if record, _ := store.Get(paid.PaymentID); record.Status != StatusProcessing {
+		t.Fatalf("status = %s, want processing until settlement is reported", record.Status)
+	}
+}
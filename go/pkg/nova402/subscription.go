@@ -0,0 +1,122 @@
+package nova402
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Subscription issues one payment per billing period for a recurring resource.
+//
+// Periods are fixed windows of Interval starting at Start. Each payment's
+// authorization is valid only for the period it pays for: ValidAfter is the
+// period start and ValidBefore the period end, so an unused authorization
+// expires on its own when the period rolls over. Renewal is simply calling
+// NextPayment again once a new period has begun; periods that elapse without
+// a call are skipped rather than billed retroactively.
+type Subscription struct {
+	// Requirements is the template each period's payment is built from
+	Requirements PaymentRequirements
+	// Interval is the length of one billing period
+	Interval time.Duration
+	// Amount is the base-unit amount paid per period
+	Amount string
+	// Start is when the first period begins
+	Start time.Time
+	// MaxPeriods limits how many periods are paid; zero means unlimited
+	MaxPeriods int
+
+	client     *Client
+	now        func() time.Time
+	mu         sync.Mutex
+	nextPeriod int
+}
+
+// NewSubscription creates a subscription paying amount every interval,
+// starting now. An empty amount pays the requirements' MaxAmountRequired.
+func (c *Client) NewSubscription(requirements PaymentRequirements, interval time.Duration, amount string) (*Subscription, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("subscription interval must be positive")
+	}
+	if !IsEVMNetwork(requirements.Network) {
+		return nil, fmt.Errorf("%w: subscriptions require an EVM network, got %s", ErrUnsupportedNetwork, requirements.Network)
+	}
+	if amount == "" {
+		amount = requirements.MaxAmountRequired
+	}
+	if value, ok := new(big.Int).SetString(amount, 10); !ok || value.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid subscription amount %q: must be a positive integer", amount)
+	}
+
+	return &Subscription{
+		Requirements: requirements,
+		Interval:     interval,
+		Amount:       amount,
+		Start:        time.Now(),
+		client:       c,
+		now:          time.Now,
+	}, nil
+}
+
+// Period returns the index of the billing period containing the current time
+func (s *Subscription) Period() int {
+	return s.periodAt(s.now())
+}
+
+// NextDue returns when the next unpaid period begins
+func (s *Subscription) NextDue() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Start.Add(time.Duration(s.nextPeriod) * s.Interval)
+}
+
+// NextPayment builds a payment header for the current period. It returns
+// ErrPeriodAlreadyPaid if the current period has already been paid and
+// ErrSubscriptionEnded once MaxPeriods have elapsed.
+func (s *Subscription) NextPayment() (PaymentHeader, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Before(s.Start) {
+		return PaymentHeader{}, fmt.Errorf("subscription starts at %s", s.Start.Format(time.RFC3339))
+	}
+
+	period := s.periodAt(now)
+	if s.MaxPeriods > 0 && period >= s.MaxPeriods {
+		return PaymentHeader{}, ErrSubscriptionEnded
+	}
+	if period < s.nextPeriod {
+		return PaymentHeader{}, fmt.Errorf("%w: next payment due %s", ErrPeriodAlreadyPaid,
+			s.Start.Add(time.Duration(s.nextPeriod)*s.Interval).Format(time.RFC3339))
+	}
+
+	periodStart := s.Start.Add(time.Duration(period) * s.Interval)
+	periodEnd := periodStart.Add(s.Interval)
+
+	requirements := s.Requirements
+	requirements.MaxAmountRequired = s.Amount
+	auth, err := s.client.buildAuthorization(context.Background(), requirements, periodStart.Unix(), periodEnd.Unix())
+	if err != nil {
+		return PaymentHeader{}, err
+	}
+
+	s.nextPeriod = period + 1
+	return PaymentHeader{
+		X402Version: X402Version,
+		Scheme:      string(SchemeSubscription),
+		Network:     requirements.Network,
+		Payload: PaymentPayload{
+			Authorization: auth,
+		},
+	}, nil
+}
+
+func (s *Subscription) periodAt(t time.Time) int {
+	if t.Before(s.Start) {
+		return 0
+	}
+	return int(t.Sub(s.Start) / s.Interval)
+}
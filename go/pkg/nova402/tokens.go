@@ -0,0 +1,110 @@
+package nova402
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// USDCSymbol is the symbol USDC deployments are registered under
+const USDCSymbol = "USDC"
+
+// Token describes an ERC-20 contract or SPL mint on a network
+type Token struct {
+	Symbol   string
+	Address  string
+	Decimals int
+}
+
+// TokenRegistry maps (network, symbol) pairs to token contracts. It is safe
+// for concurrent use.
+type TokenRegistry struct {
+	mu     sync.RWMutex
+	tokens map[string]map[string]Token
+}
+
+// NewTokenRegistry creates an empty token registry
+func NewTokenRegistry() *TokenRegistry {
+	return &TokenRegistry{tokens: make(map[string]map[string]Token)}
+}
+
+// DefaultTokens is the registry the client resolves assets against. It is
+// seeded with USDCAddresses and USDCDecimals.
+var DefaultTokens = newDefaultTokens()
+
+func newDefaultTokens() *TokenRegistry {
+	r := NewTokenRegistry()
+	for network, address := range USDCAddresses {
+		decimals, exists := USDCDecimals[network]
+		if !exists {
+			decimals = defaultUSDCDecimals
+		}
+		r.Register(network, Token{Symbol: USDCSymbol, Address: address, Decimals: decimals}, true)
+	}
+	return r
+}
+
+// Register adds a token on network. It fails with ErrTokenExists if the
+// symbol is already registered there, unless overwrite is set.
+func (r *TokenRegistry) Register(network string, token Token, overwrite bool) error {
+	if token.Symbol == "" || token.Address == "" {
+		return fmt.Errorf("token symbol and address are required")
+	}
+	if token.Decimals < 0 {
+		return fmt.Errorf("token decimals must not be negative")
+	}
+	symbol := strings.ToUpper(token.Symbol)
+	network = canonicalNetwork(network)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tokens[network][symbol]; exists && !overwrite {
+		return fmt.Errorf("%w: %s already configured for %s", ErrTokenExists, symbol, network)
+	}
+	if r.tokens[network] == nil {
+		r.tokens[network] = make(map[string]Token)
+	}
+	r.tokens[network][symbol] = token
+	return nil
+}
+
+// Lookup returns the token registered under symbol on network
+func (r *TokenRegistry) Lookup(network, symbol string) (Token, bool) {
+	network = canonicalNetwork(network)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	token, exists := r.tokens[network][strings.ToUpper(symbol)]
+	return token, exists
+}
+
+// LookupAddress returns the token with the given contract or mint address on
+// network. EVM addresses match case-insensitively.
+func (r *TokenRegistry) LookupAddress(network, address string) (Token, bool) {
+	network = canonicalNetwork(network)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, token := range r.tokens[network] {
+		if sameAddress(token.Address, address) {
+			return token, true
+		}
+	}
+	return Token{}, false
+}
+
+// sameAddress compares addresses, ignoring case for 0x-prefixed EVM addresses
+func sameAddress(a, b string) bool {
+	if strings.HasPrefix(a, "0x") && strings.HasPrefix(b, "0x") {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// requirementsAsset returns the token the requirements are priced in,
+// falling back to the network's USDC when Asset is empty
+func requirementsAsset(requirements PaymentRequirements) (string, error) {
+	if requirements.Asset != "" {
+		return requirements.Asset, nil
+	}
+	return GetUSDCAddress(requirements.Network)
+}
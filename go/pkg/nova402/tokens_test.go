@@ -0,0 +1,69 @@
+package nova402
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTokenRegistry(t *testing.T) {
+	r := NewTokenRegistry()
+	dai := Token{Symbol: "dai", Address: "0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb", Decimals: 18}
+	if err := r.Register("base", dai, false); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := r.Register("base-mainnet", dai, false); !errors.Is(err, ErrTokenExists) {
+		t.Fatalf("duplicate Register: err = %v, want ErrTokenExists", err)
+	}
+	if err := r.Register("base-mainnet", dai, true); err != nil {
+		t.Fatalf("Register with overwrite: %v", err)
+	}
+
+	if token, ok := r.Lookup("base-mainnet", "DAI"); !ok || token.Decimals != 18 {
+		t.Fatalf("Lookup = %+v, %v; want DAI with 18 decimals", token, ok)
+	}
+	if token, ok := r.LookupAddress("base-mainnet", "0x50c5725949a6f0c72e6c4a641f24049a917db0cb"); !ok || token.Address != dai.Address {
+		t.Fatalf("LookupAddress = %+v, %v; want DAI", token, ok)
+	}
+	if _, ok := r.Lookup("base-sepolia", "DAI"); ok {
+		t.Fatal("token registered on base-mainnet found on base-sepolia")
+	}
+
+	for name, token := range map[string]Token{
+		"missing symbol":    {Address: dai.Address},
+		"missing address":   {Symbol: "DAI"},
+		"negative decimals": {Symbol: "DAI", Address: dai.Address, Decimals: -1},
+	} {
+		if err := r.Register("base-mainnet", token, true); err == nil {
+			t.Fatalf("%s: Register succeeded, want error", name)
+		}
+	}
+}
+
+func TestDefaultTokensSeededWithUSDC(t *testing.T) {
+	if address, err := GetUSDCAddress("polygon"); err != nil || address != USDCAddresses["polygon"] {
+		t.Fatalf("polygon USDC = %s, %v; want %s", address, err, USDCAddresses["polygon"])
+	}
+	token, ok := DefaultTokens.LookupAddress("solana-devnet", USDCAddresses["solana-devnet"])
+	if !ok || token.Symbol != USDCSymbol || token.Decimals != 6 {
+		t.Fatalf("solana-devnet USDC = %+v, %v; want USDC with 6 decimals", token, ok)
+	}
+}
+
+func TestAuthorizationDomainForAsset(t *testing.T) {
+	domain, err := authorizationDomain(PaymentRequirements{
+		Network: "base-mainnet",
+		Asset:   "0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb",
+		Extra:   map[string]interface{}{"name": "Dai Stablecoin", "version": "1"},
+	})
+	if err != nil {
+		t.Fatalf("authorizationDomain: %v", err)
+	}
+	if domain.Name != "Dai Stablecoin" || domain.VerifyingContract != "0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb" {
+		t.Fatalf("domain = %+v, want the DAI contract and name", domain)
+	}
+
+	domain, err = authorizationDomain(PaymentRequirements{Network: "base-sepolia", Asset: USDCAddresses["base-sepolia"]})
+	if err != nil || domain.Name != "USDC" {
+		t.Fatalf("base-sepolia USDC domain = %+v, %v; want name USDC", domain, err)
+	}
+}
@@ -1,6 +1,9 @@
 package nova402
 
-import "time"
+import (
+	"net/http"
+	"time"
+)
 
 // PaymentScheme represents the payment scheme type
 type PaymentScheme string
@@ -9,6 +12,10 @@ const (
 	SchemeExact        PaymentScheme = "exact"
 	SchemeUpto         PaymentScheme = "upto"
 	SchemeSubscription PaymentScheme = "subscription"
+
+	// SchemeMulti marks a payment header that bundles one payment per
+	// requirement of a multi 402 response; see PaymentHeader.Payments
+	SchemeMulti PaymentScheme = "multi"
 )
 
 // NetworkType represents the blockchain type
@@ -21,17 +28,17 @@ const (
 
 // PaymentRequirements represents x402 payment requirements
 type PaymentRequirements struct {
-	X402Version        int                    `json:"x402Version"`
-	Scheme             string                 `json:"scheme"`
-	Network            string                 `json:"network"`
-	MaxAmountRequired  string                 `json:"maxAmountRequired"`
-	Resource           string                 `json:"resource"`
-	Description        string                 `json:"description"`
-	MimeType           string                 `json:"mimeType"`
-	PayTo              string                 `json:"payTo"`
-	MaxTimeoutSeconds  int                    `json:"maxTimeoutSeconds"`
-	Asset              string                 `json:"asset"`
-	Extra              map[string]interface{} `json:"extra,omitempty"`
+	X402Version       int                    `json:"x402Version"`
+	Scheme            string                 `json:"scheme"`
+	Network           string                 `json:"network"`
+	MaxAmountRequired string                 `json:"maxAmountRequired"`
+	Resource          string                 `json:"resource"`
+	Description       string                 `json:"description"`
+	MimeType          string                 `json:"mimeType"`
+	PayTo             string                 `json:"payTo"`
+	MaxTimeoutSeconds int                    `json:"maxTimeoutSeconds"`
+	Asset             string                 `json:"asset"`
+	Extra             map[string]interface{} `json:"extra,omitempty"`
 }
 
 // EIP3009Authorization represents EIP-3009 authorization data
@@ -45,11 +52,30 @@ type EIP3009Authorization struct {
 	V           int    `json:"v"`
 	R           string `json:"r"`
 	S           string `json:"s"`
+
+	// Signature is the hex signature of a contract wallet From, which need
+	// not be 65 bytes and is validated with EIP-1271 rather than recovered.
+	// V, R and S are left empty when it is set.
+	Signature string `json:"signature,omitempty"`
+}
+
+// EIP2612Permit is a signed permit letting Spender transfer Value from Owner
+// until Deadline, used for tokens without EIP-3009
+type EIP2612Permit struct {
+	Owner    string `json:"owner"`
+	Spender  string `json:"spender"`
+	Value    string `json:"value"`
+	Nonce    string `json:"nonce"`
+	Deadline int64  `json:"deadline"`
+	V        int    `json:"v"`
+	R        string `json:"r"`
+	S        string `json:"s"`
 }
 
 // PaymentPayload represents the payment payload
 type PaymentPayload struct {
 	Authorization *EIP3009Authorization `json:"authorization,omitempty"`
+	Permit        *EIP2612Permit        `json:"permit,omitempty"`
 	Transaction   *string               `json:"transaction,omitempty"`
 	Signatures    []string              `json:"signatures,omitempty"`
 }
@@ -60,6 +86,11 @@ type PaymentHeader struct {
 	Scheme      string         `json:"scheme"`
 	Network     string         `json:"network"`
 	Payload     PaymentPayload `json:"payload"`
+
+	// Payments holds a complete payment for each requirement of a multi 402
+	// response, in the order of its accepts, when Scheme is SchemeMulti.
+	// Network and Payload are then empty.
+	Payments []PaymentHeader `json:"payments,omitempty"`
 }
 
 // Payment402Response represents a 402 Payment Required response
@@ -67,6 +98,10 @@ type Payment402Response struct {
 	X402Version int                   `json:"x402Version"`
 	Accepts     []PaymentRequirements `json:"accepts"`
 	Error       *string               `json:"error,omitempty"`
+
+	// Multi asks for every requirement in Accepts to be paid together, as
+	// when a fee is split between payees, instead of any one of them
+	Multi bool `json:"multi,omitempty"`
 }
 
 // VerificationResult represents payment verification result
@@ -83,6 +118,32 @@ type SettlementResult struct {
 	NetworkID   *string `json:"networkId,omitempty"`
 	BlockNumber *int64  `json:"blockNumber,omitempty"`
 	Error       *string `json:"error,omitempty"`
+
+	// SettlementAttempts is every attempt the facilitator reported, in
+	// order, when it reported more than one; the result itself is the last
+	// successful attempt, or the last attempt if none succeeded
+	SettlementAttempts []SettlementResult `json:"settlementAttempts,omitempty"`
+}
+
+// PaidResponse is the outcome of a request made with automatic payment
+type PaidResponse struct {
+	// Response is the final response from the resource server
+	Response *http.Response
+	// Payment is the X-PAYMENT header sent, nil if no payment was required
+	Payment *PaymentHeader
+	// Requirements is the requirement that was paid, nil if no payment was
+	// required or several were paid at once
+	Requirements *PaymentRequirements
+	// MultiRequirements are the requirements paid by a multi payment, in the
+	// order of Payment.Payments
+	MultiRequirements []PaymentRequirements
+	// Settlement is decoded from the X-PAYMENT-RESPONSE header of the final
+	// response, nil when the server sent none
+	Settlement *SettlementResult
+	// PaymentID is the ID of the payment recorded in the client's
+	// PaymentStore, empty when no store is configured. Each part of a multi
+	// payment is recorded separately and this is the first part's ID.
+	PaymentID string
 }
 
 // NetworkConfig represents blockchain network configuration
@@ -91,6 +152,7 @@ type NetworkConfig struct {
 	Name     string      `json:"name"`
 	Type     NetworkType `json:"type"`
 	RPCUrl   string      `json:"rpcUrl"`
+	RPCUrls  []string    `json:"rpcUrls,omitempty"` // fallbacks tried in order when RPCUrl fails
 	Explorer string      `json:"explorer,omitempty"`
 	Currency Currency    `json:"currency"`
 }
@@ -147,4 +209,3 @@ type PaymentPrice struct {
 	Asset  string `json:"asset"`
 	Symbol string `json:"symbol"`
 }
-
@@ -0,0 +1,126 @@
+package nova402
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// uptoAuthorizations tracks the reusable "upto" authorization for each payee
+// and asset along with how much has been committed against it
+type uptoAuthorizations struct {
+	mu      sync.Mutex
+	entries map[string]*uptoAuthorization
+}
+
+type uptoAuthorization struct {
+	auth      *EIP3009Authorization
+	budget    *big.Int
+	committed *big.Int
+}
+
+// forget drops the authorization with the given nonce so it is not reused
+func (a *uptoAuthorizations) forget(nonce string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key, entry := range a.entries {
+		if strings.EqualFold(entry.auth.Nonce, nonce) {
+			delete(a.entries, key)
+		}
+	}
+}
+
+// uptoReuseValue returns the value a reusable upto authorization for
+// requirements is signed for: UptoReuseBudget, capped by MaxAmountRequired and
+// the network's MaxPaymentAmount. It returns "" when reuse does not apply.
+func (c *Client) uptoReuseValue(requirements PaymentRequirements) (string, error) {
+	if c.UptoReuseBudget == "" || PaymentScheme(requirements.Scheme) != SchemeUpto {
+		return "", nil
+	}
+
+	value, ok := new(big.Int).SetString(c.UptoReuseBudget, 10)
+	if !ok || value.Sign() <= 0 {
+		return "", fmt.Errorf("invalid upto reuse budget %q: must be a positive integer", c.UptoReuseBudget)
+	}
+	max, ok := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid maxAmountRequired %q", requirements.MaxAmountRequired)
+	}
+	if max.Cmp(value) < 0 {
+		value = max
+	}
+	if limit := networkLimit(c.MaxPaymentAmount, requirements.Network); limit != nil && limit.Sign() > 0 && limit.Cmp(value) < 0 {
+		value = limit
+	}
+	return value.String(), nil
+}
+
+// drawableValue returns what a payment of amount for requirements lets the
+// payee draw: the signed value of a reused upto authorization, or amount
+func (c *Client) drawableValue(requirements PaymentRequirements, amount string) (string, error) {
+	reuseValue, err := c.uptoReuseValue(requirements)
+	if err != nil || reuseValue == "" {
+		return amount, err
+	}
+	return reuseValue, nil
+}
+
+// uptoAuthorization returns a reusable authorization covering the requirements'
+// amount, signing a new one when the current one is expired or exhausted. It
+// returns nil when reuse does not apply.
+func (c *Client) uptoAuthorization(ctx context.Context, requirements PaymentRequirements, now time.Time) (*EIP3009Authorization, error) {
+	reuseValue, err := c.uptoReuseValue(requirements)
+	if err != nil || reuseValue == "" {
+		return nil, err
+	}
+	budget, _ := new(big.Int).SetString(reuseValue, 10)
+
+	value, err := c.paymentValue(ctx, requirements)
+	if err != nil {
+		return nil, err
+	}
+	amount, _ := new(big.Int).SetString(value, 10)
+	if amount.Cmp(budget) > 0 {
+		return nil, fmt.Errorf("payment of %s exceeds the upto reuse budget of %s", amount, budget)
+	}
+
+	asset, err := requirementsAsset(requirements)
+	if err != nil {
+		return nil, err
+	}
+	key := canonicalNetwork(requirements.Network) + "|" + strings.ToLower(requirements.PayTo) + "|" + strings.ToLower(asset)
+
+	c.uptoAuths.mu.Lock()
+	entry := c.uptoAuths.entries[key]
+	if entry != nil && now.Unix() < entry.auth.ValidBefore {
+		committed := new(big.Int).Add(entry.committed, amount)
+		if committed.Cmp(entry.budget) <= 0 {
+			entry.committed = committed
+			c.uptoAuths.mu.Unlock()
+			return entry.auth, nil
+		}
+	}
+	// Signing can be slow with an external Signer, so it happens unlocked
+	c.uptoAuths.mu.Unlock()
+
+	validAfter, validBefore := c.validityWindow(requirements, now)
+	auth, err := c.signAuthorizationFor(ctx, requirements, budget.String(), validAfter, validBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	c.uptoAuths.mu.Lock()
+	defer c.uptoAuths.mu.Unlock()
+	if c.uptoAuths.entries == nil {
+		c.uptoAuths.entries = make(map[string]*uptoAuthorization)
+	}
+	c.uptoAuths.entries[key] = &uptoAuthorization{
+		auth:      auth,
+		budget:    budget,
+		committed: amount,
+	}
+	return auth, nil
+}
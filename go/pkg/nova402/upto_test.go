@@ -0,0 +1,173 @@
+package nova402
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// uptoRequirements returns upto requirements for at most 1000 base units of
+// USDC on base-sepolia, valid for a minute
+func uptoRequirements() PaymentRequirements {
+	requirements := testRequirements()
+	requirements.Scheme = "upto"
+	return requirements
+}
+
+func TestUptoAuthorizationReusedWithinWindow(t *testing.T) {
+	c := NewClient("base-sepolia", "", WithUptoAuthorizationReuse("3000")).WithPrivateKey(testKey)
+	c.PayAmount = "1000"
+	requirements := uptoRequirements()
+	requirements.MaxAmountRequired = "5000"
+	now := time.Now()
+
+	first, err := c.uptoAuthorization(context.Background(), requirements, now)
+	if err != nil {
+		t.Fatalf("uptoAuthorization: %v", err)
+	}
+	if first.Value != "3000" {
+		t.Fatalf("authorization value = %s, want the 3000 reuse budget", first.Value)
+	}
+	for _, offset := range []time.Duration{10 * time.Second, 20 * time.Second} {
+		again, err := c.uptoAuthorization(context.Background(), requirements, now.Add(offset))
+		if err != nil {
+			t.Fatalf("uptoAuthorization at +%v: %v", offset, err)
+		}
+		if again.Nonce != first.Nonce {
+			t.Fatalf("new nonce at +%v while the window and budget remain", offset)
+		}
+	}
+
+	// The budget covers three payments of 1000, so the fourth needs a new one
+	fourth, err := c.uptoAuthorization(context.Background(), requirements, now.Add(30*time.Second))
+	if err != nil {
+		t.Fatalf("uptoAuthorization: %v", err)
+	}
+	if fourth.Nonce == first.Nonce {
+		t.Fatal("nonce reused past the authorization's budget")
+	}
+}
+
+func TestUptoAuthorizationSignedWithinLimits(t *testing.T) {
+	requirements := uptoRequirements()
+
+	c := NewClient("base-sepolia", "", WithUptoAuthorizationReuse("1000000")).WithPrivateKey(testKey)
+	auth, err := c.uptoAuthorization(context.Background(), requirements, time.Now())
+	if err != nil {
+		t.Fatalf("uptoAuthorization: %v", err)
+	}
+	if auth.Value != "1000" {
+		t.Fatalf("authorization value = %s, want maxAmountRequired 1000", auth.Value)
+	}
+
+	c = NewClient("base-sepolia", "",
+		WithUptoAuthorizationReuse("1000000"),
+		WithMaxPaymentAmount("base-sepolia", big.NewInt(400))).WithPrivateKey(testKey)
+	c.PayAmount = "100"
+	auth, err = c.uptoAuthorization(context.Background(), requirements, time.Now())
+	if err != nil {
+		t.Fatalf("uptoAuthorization: %v", err)
+	}
+	if auth.Value != "400" {
+		t.Fatalf("authorization value = %s, want the 400 payment limit", auth.Value)
+	}
+}
+
+func TestUptoReuseRecordsAmountDrawn(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		values []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoded := r.Header.Get("X-PAYMENT")
+		if encoded == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(strings.Replace(paid402, `"exact"`, `"upto"`, 1)))
+			return
+		}
+		if payment, err := DecodePaymentHeader(encoded); err == nil && payment.Payload.Authorization != nil {
+			mu.Lock()
+			values = append(values, payment.Payload.Authorization.Value)
+			mu.Unlock()
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "",
+		WithUptoAuthorizationReuse("1000000"),
+		WithMaxPaymentAmount("base-sepolia", big.NewInt(1000)),
+		WithSpendTracker(NewSpendTracker())).WithPrivateKey(testKey)
+	c.PayAmount = "10"
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get(srv.URL, nil)
+		if err != nil {
+			t.Fatalf("Get %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(values) != 3 {
+		t.Fatalf("server saw %d authorizations, want 3", len(values))
+	}
+	for _, value := range values {
+		if value != "1000" {
+			t.Fatalf("authorization value = %s, want 1000", value)
+		}
+	}
+	if spent, _ := c.TotalSpent("base-sepolia"); spent.Int64() != 30 {
+		t.Fatalf("TotalSpent = %v, want the 30 drawn", spent)
+	}
+}
+
+func TestUptoAuthorizationRenewedAfterWindow(t *testing.T) {
+	c := NewClient("base-sepolia", "", WithUptoAuthorizationReuse("100000")).WithPrivateKey(testKey)
+	requirements := uptoRequirements()
+	now := time.Now()
+
+	first, err := c.uptoAuthorization(context.Background(), requirements, now)
+	if err != nil {
+		t.Fatalf("uptoAuthorization: %v", err)
+	}
+	later, err := c.uptoAuthorization(context.Background(), requirements, now.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("uptoAuthorization: %v", err)
+	}
+	if later.Nonce == first.Nonce {
+		t.Fatal("nonce reused after the validity window expired")
+	}
+}
+
+func TestWithAmount(t *testing.T) {
+	c := &Client{}
+	requirements := uptoRequirements()
+	if value, err := c.paymentValue(WithAmount(context.Background(), "250"), requirements); err != nil || value != "250" {
+		t.Fatalf("paymentValue with WithAmount(250) = %q, %v; want 250", value, err)
+	}
+
+	// Without a per-request amount the client's PayAmount applies
+	c.PayAmount = "500"
+	if value, err := c.paymentValue(context.Background(), requirements); err != nil || value != "500" {
+		t.Fatalf("paymentValue with PayAmount 500 = %q, %v; want 500", value, err)
+	}
+	if _, err := c.paymentValue(WithAmount(context.Background(), "2000"), requirements); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("amount above the maximum: err = %v, want ErrInvalidAmount", err)
+	}
+}
+
+func TestWithAmountExactScheme(t *testing.T) {
+	c := &Client{}
+	requirements := testRequirements()
+	if _, err := c.paymentValue(WithAmount(context.Background(), "250"), requirements); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("partial exact payment: err = %v, want ErrInvalidAmount", err)
+	}
+	if value, err := c.paymentValue(WithAmount(context.Background(), "1000"), requirements); err != nil || value != "1000" {
+		t.Fatalf("paymentValue with the exact amount = %q, %v; want 1000", value, err)
+	}
+}
@@ -0,0 +1,117 @@
+package nova402
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Validate checks that payment requirements are well-formed before anything is signed
+func (r PaymentRequirements) Validate() error {
+	if !IsSupportedVersion(r.X402Version) {
+		return fmt.Errorf("%w: %w %d", ErrInvalidRequirements, ErrUnsupportedVersion, r.X402Version)
+	}
+
+	if !isSupportedScheme(r.Scheme) {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrInvalidRequirements, r.Scheme)
+	}
+
+	config, err := GetNetworkConfig(r.Network)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRequirements, err)
+	}
+
+	amount, ok := new(big.Int).SetString(r.MaxAmountRequired, 10)
+	if !ok || amount.Sign() <= 0 {
+		return fmt.Errorf("%w: maxAmountRequired %q is not a positive integer", ErrInvalidRequirements, r.MaxAmountRequired)
+	}
+
+	if !isValidAddress(r.PayTo, config.Type) {
+		return fmt.Errorf("%w: payTo %q is not a valid %s address", ErrInvalidRequirements, r.PayTo, config.Type)
+	}
+
+	if config.Type == NetworkTypeEVM {
+		authType, err := authorizationType(r)
+		if err != nil {
+			return err
+		}
+		if authType == AuthTypePermit {
+			if _, err := permitSpender(r); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.MaxTimeoutSeconds <= 0 {
+		return fmt.Errorf("%w: maxTimeoutSeconds must be positive", ErrInvalidRequirements)
+	}
+
+	return nil
+}
+
+func isSupportedScheme(scheme string) bool {
+	for _, s := range SupportedSchemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// decodePayment402 parses a 402 response body and checks every accepted
+// requirement names its scheme, network, payee and amount. With strict set,
+// fields this package does not know and trailing data are rejected too.
+func decodePayment402(body []byte, strict bool) (*Payment402Response, error) {
+	if strict {
+		if err := checkStrictPayment402(body); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPaymentRequired, err)
+		}
+	}
+
+	var response Payment402Response
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPaymentRequired, err)
+	}
+
+	for i, accept := range response.Accepts {
+		for _, field := range []struct{ name, value string }{
+			{"scheme", accept.Scheme},
+			{"network", accept.Network},
+			{"payTo", accept.PayTo},
+			{"maxAmountRequired", accept.MaxAmountRequired},
+		} {
+			if field.value == "" {
+				return nil, fmt.Errorf("%w: accepts[%d].%s is empty", ErrInvalidPaymentRequired, i, field.name)
+			}
+		}
+	}
+	return &response, nil
+}
+
+// checkStrictPayment402 decodes body with unknown fields disallowed. The
+// custom UnmarshalJSON methods decode leniently, so the check goes through
+// aliases without them, keeping x402Version raw as it may be a string.
+func checkStrictPayment402(body []byte) error {
+	type requirementsAlias PaymentRequirements
+	type responseAlias Payment402Response
+	var strict struct {
+		X402Version json.RawMessage `json:"x402Version"`
+		Accepts     []struct {
+			X402Version json.RawMessage `json:"x402Version"`
+			requirementsAlias
+		} `json:"accepts"`
+		responseAlias
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&strict); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after 402 response")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package nova402
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodePayment402(t *testing.T) {
+	if _, err := decodePayment402([]byte(paid402), true); err != nil {
+		t.Fatalf("strict decode of a valid 402: %v", err)
+	}
+	// Versions as strings and arbitrary extra fields are part of the spec
+	lenient := `{"x402Version":"1","accepts":[{"x402Version":"1","scheme":"exact","network":"base","maxAmountRequired":"1","payTo":"x","extra":{"q":1}}]}`
+	if _, err := decodePayment402([]byte(lenient), true); err != nil {
+		t.Fatalf("strict decode with string versions and extra: %v", err)
+	}
+}
+
+func TestDecodePayment402UnknownFields(t *testing.T) {
+	unknown := strings.Replace(paid402, `"scheme"`, `"bogus":1,"scheme"`, 1)
+	if _, err := decodePayment402([]byte(unknown), false); err != nil {
+		t.Fatalf("lenient decode rejected an unknown field: %v", err)
+	}
+	if _, err := decodePayment402([]byte(unknown), true); !errors.Is(err, ErrInvalidPaymentRequired) {
+		t.Fatalf("strict decode err = %v, want ErrInvalidPaymentRequired", err)
+	}
+	if _, err := decodePayment402([]byte(paid402+`{}`), true); err == nil {
+		t.Fatal("strict decode accepted trailing data")
+	}
+}
+
+func TestDecodePayment402NamesInvalidField(t *testing.T) {
+	body := strings.Replace(paid402, `"payTo":"0x209693Bc6afc0C5328bA36FaF03C514EF312287C"`, `"payTo":""`, 1)
+	if _, err := decodePayment402([]byte(body), false); err == nil || !strings.Contains(err.Error(), "accepts[0].payTo") {
+		t.Fatalf("err = %v, want it to name accepts[0].payTo", err)
+	}
+}
@@ -0,0 +1,234 @@
+package nova402
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// eip1271MagicValue is what an EIP-1271 wallet's isValidSignature returns,
+// its own selector, for a signature it accepts
+const eip1271MagicValue = "1626ba7e"
+
+// VerifyAuthorizationSignature reports whether auth's v, r and s recover to
+// auth.From as a TransferWithAuthorization in the USDC EIP-712 domain of
+// network. It returns an error when the authorization is malformed rather
+// than merely signed by someone else. A contract wallet's signature cannot
+// be checked offline; use Client.VerifyAuthorizationSignature for those.
+func VerifyAuthorizationSignature(auth EIP3009Authorization, network string) (bool, error) {
+	domain, err := usdcTransferDomain(network)
+	if err != nil {
+		return false, err
+	}
+	return verifyAuthorization(domain, auth, AuthTypeTransfer)
+}
+
+// VerifyAuthorizationSignature is the package-level VerifyAuthorizationSignature
+// extended to contract wallets: when auth.From has code on network, the
+// signature is validated by calling its EIP-1271 isValidSignature over RPC
+// instead of being recovered.
+func (c *Client) VerifyAuthorizationSignature(auth EIP3009Authorization, network string) (bool, error) {
+	return c.VerifyAuthorizationSignatureWithContext(context.Background(), auth, network)
+}
+
+// VerifyAuthorizationSignatureWithContext is VerifyAuthorizationSignature
+// with a caller-supplied context
+func (c *Client) VerifyAuthorizationSignatureWithContext(ctx context.Context, auth EIP3009Authorization, network string) (bool, error) {
+	config, err := GetNetworkConfig(network)
+	if err != nil {
+		return false, err
+	}
+	domain, err := usdcTransferDomain(network)
+	if err != nil {
+		return false, err
+	}
+	return c.verifyAuthorizationOnChain(ctx, config, domain, auth, AuthTypeTransfer)
+}
+
+// usdcTransferDomain returns the EIP-712 domain of network's USDC contract
+func usdcTransferDomain(network string) (EIP712Domain, error) {
+	usdc, err := GetUSDCAddress(network)
+	if err != nil {
+		return EIP712Domain{}, err
+	}
+	return tokenDomain(network, usdc, nil)
+}
+
+// verifyAuthorization recovers the signer of auth, as the given variant in
+// domain, and compares it with auth.From
+func verifyAuthorization(domain EIP712Domain, auth EIP3009Authorization, authType string) (bool, error) {
+	if !common.IsHexAddress(auth.From) {
+		return false, fmt.Errorf("invalid authorization from address %q", auth.From)
+	}
+	sig, err := authorizationSignature(auth)
+	if err != nil {
+		return false, err
+	}
+	if len(sig) != 65 {
+		return false, fmt.Errorf("a %d-byte signature can only be checked by a contract wallet with EIP-1271", len(sig))
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return false, fmt.Errorf("invalid authorization v %d", sig[64])
+	}
+
+	digest, err := HashTypedData(domain, authorizationTypedData(&auth, authType))
+	if err != nil {
+		return false, err
+	}
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return false, fmt.Errorf("failed to recover signer: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub) == common.HexToAddress(auth.From), nil
+}
+
+// verifyAuthorizationOnChain checks auth against its From address as it is
+// deployed on config: a contract is asked through EIP-1271, while an address
+// without code has its signature recovered as in verifyAuthorization
+func (c *Client) verifyAuthorizationOnChain(ctx context.Context, config *NetworkConfig, domain EIP712Domain, auth EIP3009Authorization, authType string) (bool, error) {
+	if config.Type != NetworkTypeEVM {
+		return false, fmt.Errorf("%w: authorization verification requires an EVM network, got %s", ErrUnsupportedNetwork, config.Name)
+	}
+	if !common.IsHexAddress(auth.From) {
+		return false, fmt.Errorf("invalid authorization from address %q", auth.From)
+	}
+
+	var code string
+	if err := c.callRPC(ctx, config, "eth_getCode", []interface{}{auth.From, "latest"}, &code); err != nil {
+		return false, fmt.Errorf("failed to get code of %s: %w", auth.From, err)
+	}
+	if code == "" || code == "0x" {
+		return verifyAuthorization(domain, auth, authType)
+	}
+
+	sig, err := authorizationSignature(auth)
+	if err != nil {
+		return false, err
+	}
+	digest, err := HashTypedData(domain, authorizationTypedData(&auth, authType))
+	if err != nil {
+		return false, err
+	}
+	return c.isValidSignature(ctx, config, auth.From, digest, sig)
+}
+
+// isValidSignature calls EIP-1271 isValidSignature(digest, sig) on wallet.
+// A revert is how many wallets reject a signature, so it reports false
+// rather than an error.
+func (c *Client) isValidSignature(ctx context.Context, config *NetworkConfig, wallet string, digest, sig []byte) (bool, error) {
+	data, _ := hexutil.Decode("0x" + eip1271MagicValue)
+	data = append(data, digest...)
+	data = append(data, common.LeftPadBytes(big.NewInt(2*32).Bytes(), 32)...)
+	data = append(data, encodeDynamicBytes(sig)...)
+	call := map[string]string{"to": wallet, "data": hexutil.Encode(data)}
+
+	var result string
+	err := c.callRPC(ctx, config, "eth_call", []interface{}{call, "latest"}, &result)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.reverted() {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("isValidSignature call failed: %w", err)
+	}
+
+	raw, err := hexutil.Decode(result)
+	if err != nil {
+		return false, fmt.Errorf("invalid isValidSignature result %q: %w", result, err)
+	}
+	return len(raw) >= 4 && hexutil.Encode(raw[:4]) == "0x"+eip1271MagicValue, nil
+}
+
+// authorizationSignature returns the signature carried by auth: its Signature
+// for a contract wallet, otherwise r || s || v with v as 27 or 28
+func authorizationSignature(auth EIP3009Authorization) ([]byte, error) {
+	if auth.Signature != "" {
+		sig, err := hexutil.Decode(auth.Signature)
+		if err != nil || len(sig) == 0 {
+			return nil, fmt.Errorf("invalid authorization signature %q: must be hex", auth.Signature)
+		}
+		return sig, nil
+	}
+
+	sig := make([]byte, 0, 65)
+	for _, field := range []struct{ name, value string }{
+		{"r", auth.R},
+		{"s", auth.S},
+	} {
+		word, err := hexutil.Decode(field.value)
+		if err != nil || len(word) != 32 {
+			return nil, fmt.Errorf("invalid authorization %s %q: must be 32 bytes of hex", field.name, field.value)
+		}
+		sig = append(sig, word...)
+	}
+
+	v := auth.V
+	if v < 27 {
+		v += 27
+	}
+	if v != 27 && v != 28 {
+		return nil, fmt.Errorf("invalid authorization v %d", auth.V)
+	}
+	return append(sig, byte(v)), nil
+}
+
+// VerifySolanaPayment reports whether the serialized transaction in payload is
+// signed by expectedSigner as its fee payer: the signature over the message
+// must be valid ed25519 for that key, and must match payload.Signatures when
+// it is given. Other required signers' slots are checked when filled, and may
+// be left zeroed for a co-signer such as a facilitator. It returns an error
+// when the transaction cannot be decoded rather than merely signed by someone
+// else.
+func VerifySolanaPayment(payload PaymentPayload, expectedSigner string) (bool, error) {
+	if payload.Transaction == nil {
+		return false, fmt.Errorf("%w: payload has no transaction", ErrInvalidPaymentHeader)
+	}
+	expected, err := decodeSolanaPublicKey(expectedSigner)
+	if err != nil {
+		return false, fmt.Errorf("invalid expected signer %q: %w", expectedSigner, err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(*payload.Transaction)
+	if err != nil {
+		return false, fmt.Errorf("%w: transaction is not valid base64", ErrInvalidPaymentHeader)
+	}
+
+	signatures, message, err := splitSolanaTransaction(raw)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidPaymentHeader, err)
+	}
+	signers, err := solanaMessageSigners(message)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidPaymentHeader, err)
+	}
+	if len(signers) == 0 || len(signatures) != len(signers) {
+		return false, fmt.Errorf("%w: transaction has %d signatures for %d required signers", ErrInvalidPaymentHeader, len(signatures), len(signers))
+	}
+
+	if !bytes.Equal(signers[0], expected) {
+		return false, nil
+	}
+	if len(payload.Signatures) > 0 && payload.Signatures[0] != base58Encode(signatures[0]) {
+		return false, nil
+	}
+	zero := make([]byte, ed25519.SignatureSize)
+	for i, signature := range signatures {
+		if i > 0 && bytes.Equal(signature, zero) {
+			continue
+		}
+		if !ed25519.Verify(ed25519.PublicKey(signers[i]), message, signature) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
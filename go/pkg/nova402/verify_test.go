@@ -0,0 +1,236 @@
+package nova402
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// wrongAddressSigner signs with its key but claims a different address, so
+// its signatures do not recover to the address it reports
+type wrongAddressSigner struct{ *LocalSigner }
+
+func (wrongAddressSigner) Address() (string, error) {
+	return "0x209693Bc6afc0C5328bA36FaF03C514EF312287C", nil
+}
+
+func TestVerifyAuthorizationSignature(t *testing.T) {
+	auth := signedAuthorization(t)
+	if ok, err := VerifyAuthorizationSignature(*auth, "base-sepolia"); !ok || err != nil {
+		t.Fatalf("VerifyAuthorizationSignature = %v, %v; want true", ok, err)
+	}
+
+	tampered := *auth
+	tampered.Value = "1001"
+	if ok, _ := VerifyAuthorizationSignature(tampered, "base-sepolia"); ok {
+		t.Fatal("authorization with a changed value verified")
+	}
+	if ok, _ := VerifyAuthorizationSignature(*auth, "base-mainnet"); ok {
+		t.Fatal("authorization verified against another chain's domain")
+	}
+}
+
+func TestSignatureVerificationCatchesBadSigner(t *testing.T) {
+	local, err := NewLocalSigner(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient("base-sepolia", "", WithSignatureVerification(true), WithSigner(wrongAddressSigner{local}))
+	_, err = c.buildAuthorization(context.Background(), testRequirements(), 0, time.Now().Unix()+60)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("err = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// signedSolanaTransaction returns a serialized transaction paid for and
+// signed by a fresh key, with that key and the key of another account in it
+func signedSolanaTransaction(t *testing.T) (tx []byte, signature []byte, payer, other ed25519.PublicKey) {
+	t.Helper()
+	payer, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err = ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := decodeSolanaPublicKey(SolanaTokenProgramID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instruction := solanaInstruction{
+		ProgramID: program,
+		Accounts:  []solanaAccountMeta{{PublicKey: other, IsWritable: true}, {PublicKey: payer, IsSigner: true}},
+		Data:      []byte{1},
+	}
+	message := compileSolanaMessage(payer, []solanaInstruction{instruction}, make([]byte, 32))
+	signature = ed25519.Sign(key, message)
+	tx = appendCompactU16(nil, 1)
+	tx = append(tx, signature...)
+	tx = append(tx, message...)
+	return tx, signature, payer, other
+}
+
+func TestVerifySolanaPayment(t *testing.T) {
+	tx, signature, payer, other := signedSolanaTransaction(t)
+	encoded := base64Encode(tx)
+	payload := PaymentPayload{Transaction: &encoded, Signatures: []string{base58Encode(signature)}}
+	if ok, err := VerifySolanaPayment(payload, base58Encode(payer)); !ok || err != nil {
+		t.Fatalf("VerifySolanaPayment(payer) = %v, %v; want true", ok, err)
+	}
+	if ok, err := VerifySolanaPayment(payload, base58Encode(other)); ok || err != nil {
+		t.Fatalf("VerifySolanaPayment(other) = %v, %v; want false", ok, err)
+	}
+}
+
+func TestVerifySolanaPaymentTampered(t *testing.T) {
+	tx, _, payer, _ := signedSolanaTransaction(t)
+	tx[len(tx)-1] ^= 1
+	tampered := base64Encode(tx)
+	if ok, err := VerifySolanaPayment(PaymentPayload{Transaction: &tampered}, base58Encode(payer)); ok || err != nil {
+		t.Fatalf("tampered transaction = %v, %v; want false", ok, err)
+	}
+
+	truncated := base64Encode(tx[:40])
+	if _, err := VerifySolanaPayment(PaymentPayload{Transaction: &truncated}, base58Encode(payer)); err == nil {
+		t.Fatal("truncated transaction parsed")
+	}
+}
+
+// contractWalletABI declares the EIP-1271 check and the bytes-signature
+// variant of transferWithAuthorization that contract wallets settle with
+const contractWalletABI = `[{"name":"isValidSignature","type":"function","inputs":[{"name":"h","type":"bytes32"},{"name":"s","type":"bytes"}]},
+{"name":"transferWithAuthorization","type":"function","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"validAfter","type":"uint256"},{"name":"validBefore","type":"uint256"},{"name":"nonce","type":"bytes32"},{"name":"signature","type":"bytes"}]}]`
+
+// contractWalletNode is a JSON-RPC endpoint where wallet has code and accepts,
+// through isValidSignature, signatures made by testKey's address. It counts
+// the isValidSignature calls it receives.
+func contractWalletNode(t *testing.T, wallet string) (*httptest.Server, *atomic.Int32) {
+	parsed, err := abi.JSON(strings.NewReader(contractWalletABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := AddressFromPrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := new(atomic.Int32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string
+			Params []json.RawMessage
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		result := `null`
+		switch req.Method {
+		case "eth_getCode":
+			var address string
+			json.Unmarshal(req.Params[0], &address)
+			result = `"0x"`
+			if strings.EqualFold(address, wallet) {
+				result = `"0x6080"`
+			}
+		case "eth_call":
+			calls.Add(1)
+			var call struct{ To, Data string }
+			json.Unmarshal(req.Params[0], &call)
+			args, err := parsed.Methods["isValidSignature"].Inputs.Unpack(hexutil.MustDecode(call.Data)[4:])
+			if err != nil {
+				t.Errorf("decoding isValidSignature call: %v", err)
+			}
+			hash := args[0].([32]byte)
+			signature := append([]byte(nil), args[1].([]byte)...)
+			signature[64] -= 27
+			pub, err := crypto.SigToPub(hash[:], signature)
+			if err != nil || crypto.PubkeyToAddress(*pub).Hex() != owner {
+				w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted: GS026"}}`))
+				return
+			}
+			result = `"0x1626ba7e00000000000000000000000000000000000000000000000000000000"`
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, calls
+}
+
+func TestContractWalletAuthorization(t *testing.T) {
+	const wallet = "0x1111111111111111111111111111111111111111"
+	node, calls := contractWalletNode(t, wallet)
+	registerTestNetwork(t, "cw-test", NetworkConfig{ChainID: 9, Type: NetworkTypeEVM, RPCUrl: node.URL})
+	if err := RegisterUSDC("cw-test", "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", false); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClientWithOptions(WithNetwork("cw-test"), WithPrivateKey(testKey), WithContractWallet(wallet), WithSignatureVerification(true))
+	requirements := testRequirements()
+	requirements.Network = "cw-test"
+	validAfter, validBefore := requirements.ValidityWindow(time.Now())
+	auth, err := c.buildAuthorization(context.Background(), requirements, validAfter, validBefore)
+	if err != nil {
+		t.Fatalf("buildAuthorization: %v", err)
+	}
+	// The wallet pays, with a bytes signature checked once on chain
+	if auth.From != common.HexToAddress(wallet).Hex() || auth.Signature == "" || auth.R != "" {
+		t.Fatalf("authorization = %+v, want one from the wallet with a bytes signature", auth)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("node saw %d isValidSignature calls while signing, want 1", n)
+	}
+
+	if ok, err := c.VerifyAuthorizationSignature(*auth, "cw-test"); !ok || err != nil {
+		t.Fatalf("VerifyAuthorizationSignature = %v, %v; want true", ok, err)
+	}
+	tampered := *auth
+	tampered.Value = "999"
+	if ok, err := c.VerifyAuthorizationSignature(tampered, "cw-test"); ok || err != nil {
+		t.Fatalf("tampered VerifyAuthorizationSignature = %v, %v; want false", ok, err)
+	}
+
+	// Settlement passes the signature as bytes
+	data, err := encodeAuthorizationCall(*auth, AuthTypeTransfer)
+	if err != nil {
+		t.Fatalf("encodeAuthorizationCall: %v", err)
+	}
+	parsed, _ := abi.JSON(strings.NewReader(contractWalletABI))
+	method := parsed.Methods["transferWithAuthorization"]
+	if hexutil.Encode(data[:4]) != hexutil.Encode(method.ID) {
+		t.Fatalf("selector = %x, want %x", data[:4], method.ID)
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil || hexutil.Encode(args[6].([]byte)) != auth.Signature {
+		t.Fatalf("call arguments = %v, %v; want the wallet signature last", args, err)
+	}
+}
+
+func TestContractWalletClientVerifiesEOA(t *testing.T) {
+	node, _ := contractWalletNode(t, "0x1111111111111111111111111111111111111111")
+	registerTestNetwork(t, "cw-eoa-test", NetworkConfig{ChainID: 9, Type: NetworkTypeEVM, RPCUrl: node.URL})
+	if err := RegisterUSDC("cw-eoa-test", "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", false); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClientWithOptions(WithNetwork("cw-eoa-test"), WithPrivateKey(testKey))
+	requirements := testRequirements()
+	requirements.Network = "cw-eoa-test"
+	validAfter, validBefore := requirements.ValidityWindow(time.Now())
+	auth, err := c.buildAuthorization(context.Background(), requirements, validAfter, validBefore)
+	if err != nil {
+		t.Fatalf("buildAuthorization: %v", err)
+	}
+	if ok, err := c.VerifyAuthorizationSignature(*auth, "cw-eoa-test"); !ok || err != nil {
+		t.Fatalf("VerifyAuthorizationSignature = %v, %v; want true for an EOA", ok, err)
+	}
+}
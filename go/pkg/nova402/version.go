@@ -0,0 +1,88 @@
+package nova402
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseVersion decodes an x402Version sent either as a JSON number or as a
+// numeric string. A missing or null version decodes as zero.
+func parseVersion(raw json.RawMessage) (int, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	var number int
+	if err := json.Unmarshal(raw, &number); err == nil {
+		return number, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if number, err := strconv.Atoi(strings.TrimSpace(text)); err == nil {
+			return number, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid x402Version %s: must be an integer", raw)
+}
+
+// UnmarshalJSON accepts x402Version as either a number or a numeric string
+func (r *Payment402Response) UnmarshalJSON(data []byte) error {
+	type alias Payment402Response
+	aux := struct {
+		X402Version json.RawMessage `json:"x402Version"`
+		*alias
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	version, err := parseVersion(aux.X402Version)
+	if err != nil {
+		return err
+	}
+	r.X402Version = version
+	return nil
+}
+
+// UnmarshalJSON accepts x402Version as either a number or a numeric string
+func (h *PaymentHeader) UnmarshalJSON(data []byte) error {
+	type alias PaymentHeader
+	aux := struct {
+		X402Version json.RawMessage `json:"x402Version"`
+		*alias
+	}{alias: (*alias)(h)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	version, err := parseVersion(aux.X402Version)
+	if err != nil {
+		return err
+	}
+	h.X402Version = version
+	return nil
+}
+
+// UnmarshalJSON accepts x402Version as either a number or a numeric string
+func (r *PaymentRequirements) UnmarshalJSON(data []byte) error {
+	type alias PaymentRequirements
+	aux := struct {
+		X402Version json.RawMessage `json:"x402Version"`
+		*alias
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	version, err := parseVersion(aux.X402Version)
+	if err != nil {
+		return err
+	}
+	r.X402Version = version
+	return nil
+}
@@ -0,0 +1,102 @@
+package nova402
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// versionServer answers unpaid requests with a 402 advertising version,
+// omitting x402Version when it is empty, and records the version of the
+// X-PAYMENT header it is paid with
+func versionServer(version string, paidVersion *int) *httptest.Server {
+	body := `{"accepts":[{"scheme":"exact","network":"base-sepolia","maxAmountRequired":"1","payTo":"0x209693Bc6afc0C5328bA36FaF03C514EF312287C","maxTimeoutSeconds":60}]}`
+	if version != "" {
+		body = `{"x402Version":` + version + `,` + strings.TrimPrefix(body, "{")
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoded := r.Header.Get("X-PAYMENT")
+		if encoded == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(body))
+			return
+		}
+		header, err := DecodePaymentHeader(encoded)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		*paidVersion = header.X402Version
+	}))
+}
+
+func TestPaymentEchoesServerVersion(t *testing.T) {
+	for advertised, want := range map[string]int{"1": 1, "": X402Version} {
+		paidVersion := -1
+		srv := versionServer(advertised, &paidVersion)
+		c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+		resp, err := c.Get(srv.URL, nil)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("version %q: Get: %v", advertised, err)
+		}
+		resp.Body.Close()
+		if paidVersion != want {
+			t.Fatalf("version %q: paid with version %d, want %d", advertised, paidVersion, want)
+		}
+	}
+}
+
+func TestUnsupportedServerVersion(t *testing.T) {
+	paidVersion := -1
+	srv := versionServer("2", &paidVersion)
+	defer srv.Close()
+
+	c := NewClient("base-sepolia", "").WithPrivateKey(testKey)
+	if _, err := c.Get(srv.URL, nil); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("err = %v, want ErrUnsupportedVersion", err)
+	}
+	if paidVersion != -1 {
+		t.Fatal("client paid a server advertising an unsupported version")
+	}
+
+	err := PaymentRequirements{X402Version: 3}.Validate()
+	if !errors.Is(err, ErrUnsupportedVersion) || !errors.Is(err, ErrInvalidRequirements) {
+		t.Fatalf("Validate err = %v, want ErrInvalidRequirements and ErrUnsupportedVersion", err)
+	}
+}
+
+func TestVersionAcceptsNumberOrString(t *testing.T) {
+	for _, body := range []string{
+		`{"x402Version":1,"accepts":[{"x402Version":1,"scheme":"exact"}]}`,
+		`{"x402Version":"1","accepts":[{"x402Version":"1","scheme":"exact"}]}`,
+	} {
+		var resp Payment402Response
+		if err := json.Unmarshal([]byte(body), &resp); err != nil {
+			t.Fatalf("%s: %v", body, err)
+		}
+		if resp.X402Version != 1 || len(resp.Accepts) != 1 || resp.Accepts[0].X402Version != 1 || resp.Accepts[0].Scheme != "exact" {
+			t.Fatalf("%s decoded as %+v", body, resp)
+		}
+	}
+
+	var header PaymentHeader
+	if err := json.Unmarshal([]byte(`{"x402Version":"1","scheme":"exact","payload":{"transaction":"AQID"}}`), &header); err != nil {
+		t.Fatalf("PaymentHeader: %v", err)
+	}
+	if header.X402Version != 1 || header.Payload.Transaction == nil || *header.Payload.Transaction != "AQID" {
+		t.Fatalf("PaymentHeader decoded as %+v", header)
+	}
+}
+
+func TestVersionRejectsNonInteger(t *testing.T) {
+	for _, body := range []string{`{"x402Version":"one"}`, `{"x402Version":1.5}`, `{"x402Version":true}`} {
+		var resp Payment402Response
+		if err := json.Unmarshal([]byte(body), &resp); err == nil {
+			t.Fatalf("%s: decoded version %d, want error", body, resp.X402Version)
+		}
+	}
+}